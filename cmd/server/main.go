@@ -1,20 +1,30 @@
 package main
 
 import (
-	"github.com/99designs/gqlgen/graphql/handler"
-	"github.com/99designs/gqlgen/graphql/handler/extension"
-	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"context"
 	"github.com/99designs/gqlgen/graphql/playground"
 
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"token-transfer-api/graph"
+	"token-transfer-api/graph/loaders"
+	"token-transfer-api/internal/auth"
 	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/grpcapi"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/rest"
+	"token-transfer-api/internal/service"
 )
 
-const defaultPort = "8080"
+const (
+	defaultPort     = "8080"
+	defaultGRPCPort = "9090"
+)
 
 func main() {
 	database := db.Init()
@@ -25,23 +35,151 @@ func main() {
 		port = defaultPort
 	}
 
-	// Set up the GraphQL schema with resolvers
-	resolver := &graph.Resolver{DB: database}
-	schema := graph.NewExecutableSchema(graph.Config{Resolvers: resolver})
-	srv := handler.New(schema)
+	// Select the concurrency strategy for transfers, pessimistic locking by default
+	strategy, err := service.ParseStrategy(os.Getenv("TRANSFER_STRATEGY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	service.DefaultStrategy = strategy
+	log.Printf("Using %s transfer strategy", strategy.Name())
+
+	// Start the workers applying asynchronously submitted transfers in batches
+	queue := service.NewQueue(database, service.QueueConfig{
+		Workers:      envInt("QUEUE_WORKERS"),
+		BatchSize:    envInt("QUEUE_BATCH_SIZE"),
+		BatchWait:    envDuration("QUEUE_BATCH_WAIT"),
+		PollInterval: envDuration("QUEUE_POLL_INTERVAL"),
+	})
+	queue.Start(context.Background())
+
+	// Execute scheduled transfers once they are due, every replica may run a scheduler
+	service.NewScheduler(database, service.SchedulerConfig{
+		Interval:           envDuration("SCHEDULER_INTERVAL"),
+		CheckpointInterval: envDuration("CHECKPOINT_INTERVAL"),
+	}).Start(context.Background())
+
+	// Number committed entries for the changes feed, every replica may run a sequencer
+	service.NewSequencer(database, service.SequencerConfig{
+		Interval: envDuration("SEQUENCER_INTERVAL"),
+	}).Start(context.Background())
+
+	// Send the events of the outbox to the webhook subscriptions, every replica may run a worker
+	service.NewWebhookWorker(database, service.WebhookConfig{
+		Interval:    envDuration("WEBHOOK_INTERVAL"),
+		MaxAttempts: envInt("WEBHOOK_MAX_ATTEMPTS"),
+		Timeout:     envDuration("WEBHOOK_TIMEOUT"),
+	}).Start(context.Background())
+
+	// Check the balances against the ledger periodically, the results are published on /debug/vars
+	if interval := envDuration("RECONCILE_INTERVAL"); interval > 0 {
+		service.NewReconciler(database, interval, os.Getenv("RECONCILE_STRICT") == "true").Start(context.Background())
+	}
+
+	// Refuse transfers from or to blocklisted addresses, the file is reloaded when it changes
+	if path := os.Getenv("BLOCKLIST_FILE"); path != "" {
+		if _, err := service.LoadBlocklist(path); err != nil {
+			log.Fatal(err)
+		}
+		service.WatchBlocklist(context.Background(), path, 30*time.Second)
+	}
+
+	// Introspection and the Playground are only available outside of production
+	production := os.Getenv("APP_ENV") == "production"
+
+	config := graph.ServerConfig{
+		ComplexityLimit: envInt("GRAPHQL_COMPLEXITY_LIMIT"),
+		MaxDepth:        envInt("GRAPHQL_MAX_DEPTH"),
+		APQCacheSize:    envInt("GRAPHQL_APQ_CACHE_SIZE"),
+		Introspection:   !production,
+	}
+
+	// Only execute allowlisted queries if the persisted queries file is given, which production requires
+	path := os.Getenv("GRAPHQL_PERSISTED_QUERIES")
+	if path == "" && production {
+		log.Fatal("GRAPHQL_PERSISTED_QUERIES must be set in production, where only allowlisted queries are executed")
+	}
+	if path != "" {
+		config.PersistedQueries, err = graph.LoadPersistedQueries(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Allowing only %d persisted queries", len(config.PersistedQueries))
+	}
+
+	// Authenticate requests with API keys or JWTs, the first admin key is taken from the environment
+	if key := os.Getenv("ADMIN_API_KEY"); key != "" {
+		if err := auth.EnsureAPIKey(database, "bootstrap", "admin", models.RoleAdmin, key); err != nil {
+			log.Fatal(err)
+		}
+	}
+	verifier := &auth.JWTVerifier{
+		HMACSecret: []byte(os.Getenv("JWT_HS256_SECRET")),
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		Audience:   os.Getenv("JWT_AUDIENCE"),
+	}
+	if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		verifier.RSAKeys, err = auth.LoadJWKS(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	authenticator := &auth.Authenticator{DB: database, JWT: verifier}
+
+	// Set up the GraphQL server with resolvers
+	resolver := &graph.Resolver{DB: database, Queue: queue}
+	srv := graph.NewServer(resolver, config)
 
-	// Enable standard HTTP transports (OPTIONS, GET, POST)
-	srv.AddTransport(transport.Options{})
-	srv.AddTransport(transport.GET{})
-	srv.AddTransport(transport.POST{})
+	// Set up a Playground, authentication and the request-scoped dataloaders for the GraphQL endpoint
+	if !production {
+		http.Handle("/playground", playground.Handler("GraphQL playground", "/query"))
+		log.Printf("Connect to http://localhost:%s/playground for GraphQL playground", port)
+	}
+	http.Handle("/query", authenticator.Middleware(loaders.Middleware(database, srv)))
 
-	// Enable introspection for the GraphQL Playground
-	srv.Use(extension.Introspection{})
+	// Serve the REST API next to GraphQL, only its OpenAPI document is public
+	restHandler := rest.NewHandler(database)
+	http.Handle("/v1/", authenticator.Middleware(restHandler))
+	http.Handle("GET /v1/openapi.json", restHandler)
 
-	// Set up a Playground
-	http.Handle("/playground", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
+	// Serve the gRPC TransferService on its own port for server-to-server clients
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = defaultGRPCPort
+	}
+	listener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port: %v", err)
+	}
+	go func() {
+		log.Fatal(grpcapi.NewServer(database, authenticator).Serve(listener))
+	}()
+	log.Printf("Serving gRPC on port %s", grpcPort)
 
-	log.Printf("Connect to http://localhost:%s/playground for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// envInt reads an optional integer setting, 0 means the default value
+func envInt(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return parsed
+}
+
+// envDuration reads an optional duration setting like "5ms", 0 means the default value
+func envDuration(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return parsed
+}
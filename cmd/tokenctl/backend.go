@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/iso20022"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+	"token-transfer-api/internal/statement"
+)
+
+// backend runs the commands available both with direct database access and through the API
+type backend interface {
+	Wallet(ctx context.Context, address string) (*wallet, error)
+	Wallets(ctx context.Context, after string, limit int) ([]wallet, error)
+	Transfer(ctx context.Context, from string, to string, amount int) (int, error)
+	Mint(ctx context.Context, to string, amount int) (int, error)
+	Burn(ctx context.Context, from string, amount int) (int, error)
+	Freeze(ctx context.Context, address string, direction models.FreezeDirection, frozen bool, reason string) (*wallet, error)
+	Pause(ctx context.Context, paused bool, reason string) (*pauseState, error)
+	Reconcile(ctx context.Context, record bool, strict bool) (*service.ReconcileReport, error)
+}
+
+// actor is recorded in the audit log for the administrative actions run directly against the database
+const actor = "tokenctl"
+
+type wallet struct {
+	Address string `json:"address"`
+	Owner   string `json:"owner,omitempty"`
+	Balance int    `json:"balance"`
+	Held    int    `json:"held"`
+	Shards  int    `json:"shards"`
+
+	SendFrozen    bool   `json:"sendFrozen"`
+	ReceiveFrozen bool   `json:"receiveFrozen"`
+	FreezeReason  string `json:"freezeReason,omitempty"`
+}
+
+func toWallet(w *models.Wallet) *wallet {
+	return &wallet{
+		Address: w.Address, Owner: w.Owner, Balance: w.Balance, Held: w.Held, Shards: w.Shards,
+		SendFrozen: w.SendFrozen, ReceiveFrozen: w.ReceiveFrozen, FreezeReason: w.FreezeReason,
+	}
+}
+
+type pauseState struct {
+	Paused    bool      `json:"paused"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// connect opens the database with the same configuration as the server, without its query logging
+func connect() *gorm.DB {
+	database := db.Connect()
+	database.Logger = logger.Default.LogMode(logger.Silent)
+	return database
+}
+
+// dbBackend runs the commands directly against the database through the service layer
+type dbBackend struct {
+	db *gorm.DB
+}
+
+func (b *dbBackend) Wallet(ctx context.Context, address string) (*wallet, error) {
+	found, err := service.Wallet(b.db.WithContext(ctx), address)
+	if err != nil {
+		return nil, err
+	}
+	return toWallet(found), nil
+}
+
+func (b *dbBackend) Transfer(ctx context.Context, from string, to string, amount int) (int, error) {
+	return service.Transfer(b.db.WithContext(ctx), from, to, amount)
+}
+
+func (b *dbBackend) Mint(ctx context.Context, to string, amount int) (int, error) {
+	return service.Mint(b.db.WithContext(ctx), to, amount)
+}
+
+func (b *dbBackend) Burn(ctx context.Context, from string, amount int) (int, error) {
+	return service.Burn(b.db.WithContext(ctx), from, amount)
+}
+
+func (b *dbBackend) Freeze(ctx context.Context, address string, direction models.FreezeDirection, frozen bool, reason string) (*wallet, error) {
+	change := service.FreezeWallet
+	if !frozen {
+		change = service.UnfreezeWallet
+	}
+	found, err := change(b.db.WithContext(ctx), address, direction, reason, actor)
+	if err != nil {
+		return nil, err
+	}
+	return toWallet(found), nil
+}
+
+func (b *dbBackend) Pause(ctx context.Context, paused bool, reason string) (*pauseState, error) {
+	change := service.Pause
+	if !paused {
+		change = service.Resume
+	}
+	state, err := change(b.db.WithContext(ctx), reason, actor)
+	if err != nil {
+		return nil, err
+	}
+	return &pauseState{Paused: state.Paused, Reason: state.Reason, Actor: state.Actor, UpdatedAt: state.UpdatedAt}, nil
+}
+
+func (b *dbBackend) Wallets(ctx context.Context, after string, limit int) ([]wallet, error) {
+	found, err := service.ListWallets(b.db.WithContext(ctx), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]wallet, len(found))
+	for i := range found {
+		wallets[i] = *toWallet(&found[i])
+	}
+	return wallets, nil
+}
+
+type exportedTransfer struct {
+	ID        uint      `json:"id"`
+	Kind      string    `json:"kind"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Amount    int       `json:"amount"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Export writes the whole ledger, oldest entry first, page by page
+func (b *dbBackend) Export(out io.Writer, format string, address string) error {
+	encoder := json.NewEncoder(out)
+	writer := csv.NewWriter(out)
+	if format == "csv" {
+		if err := writer.Write([]string{"id", "kind", "from", "to", "amount", "status", "reason", "created_at", "updated_at"}); err != nil {
+			return err
+		}
+	}
+
+	var after uint
+	for {
+		var transfers []models.Transfer
+		query := b.db.Where("id > ?", after).Order("id").Limit(service.MaxPageSize)
+		if address != "" {
+			query = query.Where("from_address = ? OR to_address = ?", address, address)
+		}
+		if err := query.Find(&transfers).Error; err != nil {
+			return fmt.Errorf("failed to load transfers: %w", err)
+		}
+
+		for _, t := range transfers {
+			var err error
+			if format == "csv" {
+				err = writer.Write([]string{
+					strconv.FormatUint(uint64(t.ID), 10), string(t.Kind), t.FromAddress, t.ToAddress,
+					strconv.Itoa(t.Amount), string(t.Status), t.Reason,
+					t.CreatedAt.Format(time.RFC3339Nano), t.UpdatedAt.Format(time.RFC3339Nano),
+				})
+			} else {
+				err = encoder.Encode(exportedTransfer{
+					ID: t.ID, Kind: string(t.Kind), From: t.FromAddress, To: t.ToAddress, Amount: t.Amount,
+					Status: string(t.Status), Reason: t.Reason, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt,
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write transfer: %w", err)
+			}
+		}
+
+		if len(transfers) < service.MaxPageSize {
+			writer.Flush()
+			return writer.Error()
+		}
+		after = transfers[len(transfers)-1].ID
+	}
+}
+
+// Statement writes the statement of the wallet for [from, to) in the given format
+func (b *dbBackend) Statement(out io.Writer, format string, address string, from time.Time, to time.Time) error {
+	opened, err := service.OpenStatement(b.db, address, from, to)
+	if err != nil {
+		return err
+	}
+	return statement.Write(b.db, out, format, opened)
+}
+
+// Pain001 executes the credit transfers of the pain.001 document and writes the pain.002 status report.
+// The operator may spend from every wallet.
+func (b *dbBackend) Pain001(out io.Writer, in io.Reader) error {
+	document, err := iso20022.ParsePain001(in)
+	if err != nil {
+		return err
+	}
+	report, err := iso20022.ExecutePain001(b.db, document, actor, func(string) error { return nil })
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(report)
+	return err
+}
+
+// Reconcile checks the balances against the ledger, and records the result if record is set.
+// In strict mode, the recorded failure also pauses transfers.
+func (b *dbBackend) Reconcile(ctx context.Context, record bool, strict bool) (*service.ReconcileReport, error) {
+	if !record {
+		return service.Reconcile(b.db.WithContext(ctx))
+	}
+	run, err := service.RecordReconcile(b.db.WithContext(ctx), strict)
+	if err != nil {
+		return nil, err
+	}
+	return &service.ReconcileReport{
+		TotalSupply:      run.TotalSupply,
+		TotalBalance:     run.TotalBalance,
+		Discrepancies:    run.Discrepancies,
+		NegativeBalances: run.NegativeBalances,
+	}, nil
+}
+
+// RebuildProjections rebuilds the wallets from the event store, and overwrites those that differ unless verify is set
+func (b *dbBackend) RebuildProjections(verify bool) (*service.ProjectionReport, error) {
+	if verify {
+		return service.VerifyProjections(b.db)
+	}
+	return service.RebuildProjections(b.db)
+}
+
+// TrialBalance computes the trial balance of the journal entries created before at, with all accounts
+func (b *dbBackend) TrialBalance(at time.Time) (*service.TrialBalanceReport, error) {
+	var report *service.TrialBalanceReport
+	after := ""
+	for {
+		page, err := service.TrialBalance(b.db, at, after, service.MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if report == nil {
+			report = page
+		} else {
+			report.Lines = append(report.Lines, page.Lines...)
+		}
+		if len(page.Lines) < service.MaxPageSize {
+			return report, nil
+		}
+		after = page.Lines[len(page.Lines)-1].Account.Code
+	}
+}
+
+// GeneralLedger lists all postings of the account in journal entries created in [from, to)
+func (b *dbBackend) GeneralLedger(account string, from time.Time, to time.Time) (*service.GeneralLedgerReport, error) {
+	var report *service.GeneralLedgerReport
+	var after uint
+	for {
+		page, err := service.GeneralLedger(b.db, account, from, to, after, service.MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if report == nil {
+			report = page
+		} else {
+			report.Lines = append(report.Lines, page.Lines...)
+		}
+		if len(page.Lines) < service.MaxPageSize {
+			return report, nil
+		}
+		after = page.Lines[len(page.Lines)-1].PostingID
+	}
+}
+
+func (b *dbBackend) Migrate() {
+	db.Migrate(b.db)
+}
+
+// apiBackend runs the commands through the GraphQL API of a running server
+type apiBackend struct {
+	url    string
+	apiKey string
+}
+
+const walletFields = "address owner balance held shards sendFrozen receiveFrozen freezeReason"
+
+func (b *apiBackend) Wallet(ctx context.Context, address string) (*wallet, error) {
+	var data struct{ Wallet *wallet }
+	err := b.query(ctx, "query($address: String!) { wallet(address: $address) { "+walletFields+" } }",
+		map[string]any{"address": address}, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Wallet == nil {
+		return nil, fmt.Errorf("%w: %s", service.ErrWalletNotFound, address)
+	}
+	return data.Wallet, nil
+}
+
+func (b *apiBackend) Wallets(ctx context.Context, after string, limit int) ([]wallet, error) {
+	var data struct{ Wallets []wallet }
+	err := b.query(ctx, "query($after: String, $limit: Int) { wallets(after: $after, limit: $limit) { "+walletFields+" } }",
+		map[string]any{"after": after, "limit": limit}, &data)
+	return data.Wallets, err
+}
+
+func (b *apiBackend) Transfer(ctx context.Context, from string, to string, amount int) (int, error) {
+	var data struct{ Transfer struct{ Balance int } }
+	err := b.query(ctx, "mutation($from: String!, $to: String!, $amount: Int!) { transfer(from: $from, to: $to, amount: $amount) { balance } }",
+		map[string]any{"from": from, "to": to, "amount": amount}, &data)
+	return data.Transfer.Balance, err
+}
+
+func (b *apiBackend) Mint(ctx context.Context, to string, amount int) (int, error) {
+	var data struct{ Mint struct{ Balance int } }
+	err := b.query(ctx, "mutation($to: String!, $amount: Int!) { mint(to: $to, amount: $amount) { balance } }",
+		map[string]any{"to": to, "amount": amount}, &data)
+	return data.Mint.Balance, err
+}
+
+func (b *apiBackend) Burn(ctx context.Context, from string, amount int) (int, error) {
+	var data struct{ Burn struct{ Balance int } }
+	err := b.query(ctx, "mutation($from: String!, $amount: Int!) { burn(from: $from, amount: $amount) { balance } }",
+		map[string]any{"from": from, "amount": amount}, &data)
+	return data.Burn.Balance, err
+}
+
+func (b *apiBackend) Freeze(ctx context.Context, address string, direction models.FreezeDirection, frozen bool, reason string) (*wallet, error) {
+	mutation := "freezeWallet"
+	if !frozen {
+		mutation = "unfreezeWallet"
+	}
+	var data map[string]*wallet
+	err := b.query(ctx, "mutation($address: String!, $direction: FreezeDirection!, $reason: String!) { "+mutation+"(address: $address, direction: $direction, reason: $reason) { "+walletFields+" } }",
+		map[string]any{"address": address, "direction": direction, "reason": reason}, &data)
+	return data[mutation], err
+}
+
+func (b *apiBackend) Pause(ctx context.Context, paused bool, reason string) (*pauseState, error) {
+	mutation := "pause"
+	if !paused {
+		mutation = "resume"
+	}
+	var data map[string]*pauseState
+	err := b.query(ctx, "mutation($reason: String!) { "+mutation+"(reason: $reason) { paused reason actor updatedAt } }",
+		map[string]any{"reason": reason}, &data)
+	return data[mutation], err
+}
+
+const reportFields = "totalSupply totalBalance discrepancies { address balance ledgerBalance } negativeBalances"
+
+func (b *apiBackend) Reconcile(ctx context.Context, record bool, strict bool) (*service.ReconcileReport, error) {
+	if !record {
+		var data struct{ Reconcile *service.ReconcileReport }
+		err := b.query(ctx, "query { reconcile { "+reportFields+" } }", nil, &data)
+		return data.Reconcile, err
+	}
+	var data struct{ RecordReconcile *service.ReconcileReport }
+	err := b.query(ctx, "mutation($strict: Boolean) { recordReconcile(strict: $strict) { "+reportFields+" } }",
+		map[string]any{"strict": strict}, &data)
+	return data.RecordReconcile, err
+}
+
+// query posts the GraphQL operation to the /query endpoint and decodes its data
+func (b *apiBackend) query(ctx context.Context, query string, variables map[string]any, data any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(b.url, "/")+"/query", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", b.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return json.Unmarshal(result.Data, data)
+}
@@ -0,0 +1,434 @@
+// Command tokenctl is the administrative CLI for operators. It talks directly to the database
+// configured by the POSTGRES_* variables, or to the running API when -api is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/statement"
+)
+
+const usage = `Usage: tokenctl [-api URL] [-api-key KEY] [-output table|json] <command> [arguments]
+
+Commands:
+  wallet get ADDRESS                        show a wallet
+  wallet list [-after ADDRESS] [-limit N]   list wallets in address order
+  transfer FROM TO AMOUNT                   transfer tokens between wallets
+  mint TO AMOUNT                            create tokens in a wallet
+  burn FROM AMOUNT                          destroy tokens held by a wallet
+  freeze [-unfreeze] [-direction send|receive|both] -reason REASON ADDRESS
+                                            stop a wallet from sending, receiving or both
+  pause -reason REASON                      halt all balance changes
+  resume -reason REASON                     let balance changes happen again
+  export [-format ndjson|csv] [-address ADDRESS]
+                                            write the ledger to stdout (database only)
+  statement [-format csv|ndjson|beancount|camt053] -from DATE -to DATE ADDRESS
+                                            write the statement of a wallet to stdout (database only)
+  pain001 FILE                              execute an ISO 20022 pain.001 file, write the pain.002
+                                            status report to stdout (database only)
+  reconcile [-record] [-strict]             check the balances against the ledger
+  rebuild-projections [-verify]             rebuild the wallets from the event store, or only compare
+                                            them with the live tables with -verify (database only)
+  trial-balance [-at TIME]                  show the balances of all accounts of the journal (database only)
+  general-ledger -from DATE -to DATE ACCOUNT
+                                            show the postings of a journal account (database only)
+  migrate                                   migrate the database schema (database only)
+
+Without -api the database is configured by the POSTGRES_* variables, like the server.
+The commands marked (database only) have no API equivalent: export reads the whole ledger oldest first
+in one pass, which the paged APIs don't offer, and migrate changes the schema, which the server
+only does when it starts.
+-api and -api-key default to the TOKENCTL_API and TOKENCTL_API_KEY variables.
+`
+
+// errUsage is returned for invalid command lines, the usage is printed instead of the error
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	flags := flag.NewFlagSet("tokenctl", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	api := flags.String("api", os.Getenv("TOKENCTL_API"), "base URL of the running API, e.g. http://localhost:8080")
+	apiKey := flags.String("api-key", os.Getenv("TOKENCTL_API_KEY"), "API key used with -api")
+	output := flags.String("output", "table", "output format, table or json")
+	_ = flags.Parse(os.Args[1:])
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	cli := &cli{api: *api, apiKey: *apiKey, printer: printer{json: *output == "json", out: os.Stdout}}
+	err := cli.run(context.Background(), flags.Args())
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tokenctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type cli struct {
+	api     string
+	apiKey  string
+	printer printer
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "wallet":
+		if len(args) == 0 {
+			return errUsage
+		}
+		switch args[0] {
+		case "get":
+			return c.walletGet(ctx, args[1:])
+		case "list":
+			return c.walletList(ctx, args[1:])
+		}
+		return errUsage
+	case "transfer":
+		return c.transfer(ctx, args)
+	case "mint":
+		return c.mint(ctx, args)
+	case "burn":
+		return c.burn(ctx, args)
+	case "freeze":
+		return c.freeze(ctx, args)
+	case "pause", "resume":
+		return c.pause(ctx, command == "pause", args)
+	case "export":
+		return c.export(args)
+	case "statement":
+		return c.statement(args)
+	case "pain001":
+		return c.pain001(args)
+	case "reconcile":
+		return c.reconcile(ctx, args)
+	case "rebuild-projections":
+		return c.rebuildProjections(args)
+	case "trial-balance":
+		return c.trialBalance(args)
+	case "general-ledger":
+		return c.generalLedger(args)
+	case "migrate":
+		return c.migrate(args)
+	default:
+		return errUsage
+	}
+}
+
+// backend returns the API client when -api is given and the direct database access otherwise
+func (c *cli) backend() backend {
+	if c.api != "" {
+		return &apiBackend{url: c.api, apiKey: c.apiKey}
+	}
+	return &dbBackend{db: connect()}
+}
+
+// database returns the direct database access of the commands without an API equivalent
+func (c *cli) database() (*dbBackend, error) {
+	if c.api != "" {
+		return nil, errors.New("this command needs direct database access, run it without -api")
+	}
+	return &dbBackend{db: connect()}, nil
+}
+
+func (c *cli) walletGet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	wallet, err := c.backend().Wallet(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printer.wallets(*wallet)
+}
+
+func (c *cli) walletList(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("wallet list", flag.ContinueOnError)
+	after := flags.String("after", "", "list wallets after this address")
+	limit := flags.Int("limit", 100, "maximum number of wallets")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	wallets, err := c.backend().Wallets(ctx, *after, *limit)
+	if err != nil {
+		return err
+	}
+	return c.printer.wallets(wallets...)
+}
+
+func (c *cli) transfer(ctx context.Context, args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	amount, err := parseAmount(args[2])
+	if err != nil {
+		return err
+	}
+	balance, err := c.backend().Transfer(ctx, args[0], args[1], amount)
+	if err != nil {
+		return err
+	}
+	return c.printer.balance(args[0], balance)
+}
+
+func (c *cli) mint(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+	balance, err := c.backend().Mint(ctx, args[0], amount)
+	if err != nil {
+		return err
+	}
+	return c.printer.balance(args[0], balance)
+}
+
+func (c *cli) burn(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+	balance, err := c.backend().Burn(ctx, args[0], amount)
+	if err != nil {
+		return err
+	}
+	return c.printer.balance(args[0], balance)
+}
+
+func (c *cli) freeze(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("freeze", flag.ContinueOnError)
+	unfreeze := flags.Bool("unfreeze", false, "let the wallet send or receive tokens again")
+	direction := flags.String("direction", "both", "send, receive or both")
+	reason := flags.String("reason", "", "reason recorded in the audit log")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *reason == "" {
+		return errUsage
+	}
+
+	wallet, err := c.backend().Freeze(ctx, flags.Arg(0), models.FreezeDirection(strings.ToUpper(*direction)), !*unfreeze, *reason)
+	if err != nil {
+		return err
+	}
+	return c.printer.wallets(*wallet)
+}
+
+func (c *cli) pause(ctx context.Context, paused bool, args []string) error {
+	flags := flag.NewFlagSet("pause", flag.ContinueOnError)
+	reason := flags.String("reason", "", "reason recorded in the audit log")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *reason == "" {
+		return errUsage
+	}
+
+	state, err := c.backend().Pause(ctx, paused, *reason)
+	if err != nil {
+		return err
+	}
+	return c.printer.pauseState(state)
+}
+
+func (c *cli) export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "ndjson", "ndjson or csv")
+	address := flags.String("address", "", "only export entries sent or received by the wallet")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	if *format != "ndjson" && *format != "csv" {
+		return fmt.Errorf("unknown export format %q", *format)
+	}
+
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	return database.Export(c.printer.out, *format, *address)
+}
+
+func (c *cli) statement(args []string) error {
+	flags := flag.NewFlagSet("statement", flag.ContinueOnError)
+	format := flags.String("format", statement.FormatCSV, "csv, ndjson, beancount or camt053")
+	from := flags.String("from", "", "start of the statement, a date or an RFC 3339 time")
+	to := flags.String("to", "", "end of the statement, excluded, a date or an RFC 3339 time")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	if statement.ContentType(*format) == "" {
+		return fmt.Errorf("unknown statement format %q", *format)
+	}
+	start, err := parseDate(*from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	end, err := parseDate(*to)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	return database.Statement(c.printer.out, *format, flags.Arg(0), start, end)
+}
+
+func (c *cli) pain001(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	return database.Pain001(c.printer.out, file)
+}
+
+// parseDate accepts RFC 3339 times and plain dates, which start at midnight UTC
+func parseDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (c *cli) reconcile(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	record := flags.Bool("record", false, "store the result in the reconcile_runs table")
+	strict := flags.Bool("strict", false, "record the result and pause transfers if the check fails")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	report, err := c.backend().Reconcile(ctx, *record || *strict, *strict)
+	if err != nil {
+		return err
+	}
+	if err := c.printer.report(report); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errors.New("the balances don't match the ledger")
+	}
+	return nil
+}
+
+func (c *cli) rebuildProjections(args []string) error {
+	flags := flag.NewFlagSet("rebuild-projections", flag.ContinueOnError)
+	verify := flags.Bool("verify", false, "only compare the rebuilt wallets with the live tables")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	report, err := database.RebuildProjections(*verify)
+	if err != nil {
+		return err
+	}
+	if err := c.printer.projections(report); err != nil {
+		return err
+	}
+	if *verify && !report.OK() {
+		return errors.New("the wallets don't match the event store")
+	}
+	return nil
+}
+
+func (c *cli) trialBalance(args []string) error {
+	flags := flag.NewFlagSet("trial-balance", flag.ContinueOnError)
+	at := flags.String("at", "", "only include the journal entries created before, a date or an RFC 3339 time")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+	end := time.Now()
+	if *at != "" {
+		var err error
+		if end, err = parseDate(*at); err != nil {
+			return fmt.Errorf("invalid -at: %w", err)
+		}
+	}
+
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	report, err := database.TrialBalance(end)
+	if err != nil {
+		return err
+	}
+	return c.printer.trialBalance(report)
+}
+
+func (c *cli) generalLedger(args []string) error {
+	flags := flag.NewFlagSet("general-ledger", flag.ContinueOnError)
+	from := flags.String("from", "", "start of the period, a date or an RFC 3339 time")
+	to := flags.String("to", "", "end of the period, excluded, a date or an RFC 3339 time")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	start, err := parseDate(*from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	end, err := parseDate(*to)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	report, err := database.GeneralLedger(flags.Arg(0), start, end)
+	if err != nil {
+		return err
+	}
+	return c.printer.generalLedger(report)
+}
+
+func (c *cli) migrate(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	database, err := c.database()
+	if err != nil {
+		return err
+	}
+	database.Migrate()
+	return nil
+}
+
+func parseAmount(value string) (int, error) {
+	amount, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAPI serves canned GraphQL data by the name of the queried field and records the variables it was sent.
+// Invalid requests are answered with 400 Bad Request, failing the command.
+func fakeAPI(t *testing.T, responses map[string]string) (url string, variables *map[string]any) {
+	var received map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string
+			Variables map[string]any
+		}
+		if r.URL.Path != "/query" || r.Header.Get("X-API-Key") != "admin-key" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = req.Variables
+
+		for field, data := range responses {
+			if strings.Contains(req.Query, "{ "+field+"(") || strings.Contains(req.Query, "{ "+field+" {") {
+				_, _ = w.Write([]byte(`{"data": {"` + field + `": ` + data + `}}`))
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{"errors": [{"message": "unexpected query"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &received
+}
+
+func newCLI(api string, json bool) (*cli, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &cli{api: api, apiKey: "admin-key", printer: printer{json: json, out: out}}, out
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"wallet"},
+		{"wallet", "delete"},
+		{"wallet", "get"},
+		{"wallet", "list", "extra"},
+		{"wallet", "list", "-limit", "many"},
+		{"transfer", "A", "B"},
+		{"mint", "A"},
+		{"freeze", "A"},
+		{"freeze", "-reason", "fraud"},
+		{"pause"},
+		{"reconcile", "-verbose"},
+		{"general-ledger", "-from", "2026-09-01", "-to", "2026-10-01"},
+		{"migrate", "now"},
+	} {
+		c, out := newCLI("http://localhost:0", false)
+		require.ErrorIs(t, c.run(context.Background(), args), errUsage, "%q", args)
+		require.Empty(t, out.String())
+	}
+}
+
+func TestRun_InvalidArguments(t *testing.T) {
+	c, _ := newCLI("http://localhost:0", false)
+
+	require.EqualError(t, c.run(context.Background(), []string{"transfer", "A", "B", "ten"}), `invalid amount "ten"`)
+	require.EqualError(t, c.run(context.Background(), []string{"export", "-format", "xml"}), `unknown export format "xml"`)
+	require.ErrorContains(t, c.run(context.Background(), []string{"trial-balance", "-at", "yesterday"}), "invalid -at")
+
+	// Commands without an API equivalent refuse to run against the API
+	for _, command := range []string{"export", "migrate"} {
+		require.EqualError(t, c.run(context.Background(), []string{command}), "this command needs direct database access, run it without -api")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	parsed, err := parseDate("2026-09-01")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseDate("2026-09-01T12:30:00+02:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 9, 1, 10, 30, 0, 0, time.UTC), parsed.UTC())
+
+	_, err = parseDate("01.09.2026")
+	require.Error(t, err)
+}
+
+func TestRun_WalletListThroughAPI(t *testing.T) {
+	url, variables := fakeAPI(t, map[string]string{
+		"wallets": `[{"address": "A", "balance": 10, "held": 2, "shards": 0}, {"address": "B", "owner": "bob", "balance": 5, "sendFrozen": true, "freezeReason": "fraud"}]`,
+	})
+	c, out := newCLI(url, true)
+
+	require.NoError(t, c.run(context.Background(), []string{"wallet", "list", "-after", "0", "-limit", "2"}))
+	require.Equal(t, map[string]any{"after": "0", "limit": float64(2)}, *variables)
+
+	var wallets []wallet
+	require.NoError(t, json.Unmarshal(out.Bytes(), &wallets))
+	require.Equal(t, []wallet{
+		{Address: "A", Balance: 10, Held: 2},
+		{Address: "B", Owner: "bob", Balance: 5, SendFrozen: true, FreezeReason: "fraud"},
+	}, wallets)
+}
+
+func TestRun_ReconcileThroughAPI(t *testing.T) {
+	url, variables := fakeAPI(t, map[string]string{
+		"reconcile":       `{"totalSupply": 100, "totalBalance": 100, "discrepancies": [], "negativeBalances": []}`,
+		"recordReconcile": `{"totalSupply": 100, "totalBalance": 90, "discrepancies": [{"address": "A", "balance": 0, "ledgerBalance": 10}], "negativeBalances": []}`,
+	})
+	c, out := newCLI(url, false)
+
+	require.NoError(t, c.run(context.Background(), []string{"reconcile"}))
+	require.Contains(t, out.String(), "status         OK")
+
+	// A failed check is printed and makes the command fail
+	out.Reset()
+	require.EqualError(t, c.run(context.Background(), []string{"reconcile", "-strict"}), "the balances don't match the ledger")
+	require.Equal(t, map[string]any{"strict": true}, *variables)
+	require.Contains(t, out.String(), "discrepancy A  balance 0, ledger 10")
+	require.Contains(t, out.String(), "status         FAILED")
+}
+
+func TestRun_APIErrors(t *testing.T) {
+	url, _ := fakeAPI(t, nil)
+	c, _ := newCLI(url, false)
+
+	require.EqualError(t, c.run(context.Background(), []string{"mint", "A", "10"}), "unexpected query")
+}
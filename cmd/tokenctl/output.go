@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"token-transfer-api/internal/service"
+)
+
+// printer writes the results either as aligned tables or as indented JSON
+type printer struct {
+	json bool
+	out  io.Writer
+}
+
+func (p printer) wallets(wallets ...wallet) error {
+	if p.json {
+		if len(wallets) == 1 {
+			return p.encode(wallets[0])
+		}
+		return p.encode(wallets)
+	}
+
+	rows := make([][]string, len(wallets))
+	for i, w := range wallets {
+		rows[i] = []string{
+			w.Address, w.Owner, strconv.FormatBool(w.SendFrozen), strconv.FormatBool(w.ReceiveFrozen),
+			strconv.Itoa(w.Balance), strconv.Itoa(w.Held), strconv.Itoa(w.Shards),
+		}
+	}
+	return p.table([]string{"ADDRESS", "OWNER", "SEND FROZEN", "RECEIVE FROZEN", "BALANCE", "HELD", "SHARDS"}, rows)
+}
+
+func (p printer) pauseState(state *pauseState) error {
+	if p.json {
+		return p.encode(state)
+	}
+	return p.table([]string{"PAUSED", "REASON", "ACTOR"}, [][]string{{strconv.FormatBool(state.Paused), state.Reason, state.Actor}})
+}
+
+func (p printer) balance(address string, balance int) error {
+	if p.json {
+		return p.encode(map[string]any{"address": address, "balance": balance})
+	}
+	return p.table([]string{"ADDRESS", "BALANCE"}, [][]string{{address, strconv.Itoa(balance)}})
+}
+
+func (p printer) report(report *service.ReconcileReport) error {
+	if p.json {
+		return p.encode(report)
+	}
+
+	rows := [][]string{
+		{"total supply", strconv.Itoa(report.TotalSupply)},
+		{"total balance", strconv.Itoa(report.TotalBalance)},
+	}
+	for _, d := range report.Discrepancies {
+		rows = append(rows, []string{"discrepancy " + d.Address, fmt.Sprintf("balance %d, ledger %d", d.Balance, d.LedgerBalance)})
+	}
+	for _, address := range report.NegativeBalances {
+		rows = append(rows, []string{"negative balance", address})
+	}
+	status := "OK"
+	if !report.OK() {
+		status = "FAILED"
+	}
+	rows = append(rows, []string{"status", status})
+	return p.table([]string{"CHECK", "RESULT"}, rows)
+}
+
+func (p printer) projections(report *service.ProjectionReport) error {
+	if p.json {
+		return p.encode(report)
+	}
+
+	rows := [][]string{
+		{"events", strconv.Itoa(report.Events)},
+		{"wallets", strconv.Itoa(report.Wallets)},
+	}
+	for _, m := range report.Mismatches {
+		rows = append(rows, []string{"mismatch " + m.Rebuilt.Address, fmt.Sprintf("live %s, rebuilt %s", projection(m.Live), projection(m.Rebuilt))})
+	}
+	status := "OK"
+	if !report.OK() {
+		status = fmt.Sprintf("%d MISMATCHES", len(report.Mismatches))
+	}
+	rows = append(rows, []string{"status", status})
+	return p.table([]string{"CHECK", "RESULT"}, rows)
+}
+
+func projection(wallet service.WalletProjection) string {
+	return fmt.Sprintf("balance %d held %d send frozen %t receive frozen %t", wallet.Balance, wallet.Held, wallet.SendFrozen, wallet.ReceiveFrozen)
+}
+
+func (p printer) trialBalance(report *service.TrialBalanceReport) error {
+	if p.json {
+		return p.encode(report)
+	}
+
+	rows := make([][]string, 0, len(report.Lines)+1)
+	for _, line := range report.Lines {
+		rows = append(rows, []string{line.Account.Code, string(line.Account.Type), amount(line.Debit), amount(line.Credit)})
+	}
+	rows = append(rows, []string{"total", "", strconv.Itoa(report.Debit), strconv.Itoa(report.Credit)})
+	return p.table([]string{"ACCOUNT", "TYPE", "DEBIT", "CREDIT"}, rows)
+}
+
+func (p printer) generalLedger(report *service.GeneralLedgerReport) error {
+	if p.json {
+		return p.encode(report)
+	}
+
+	rows := [][]string{{report.From.Format(time.RFC3339), "", "opening balance", "", "", strconv.Itoa(report.Opening)}}
+	for _, line := range report.Lines {
+		rows = append(rows, []string{
+			line.Time.Format(time.RFC3339), strconv.FormatUint(uint64(line.EntryID), 10), line.Description,
+			amount(line.Debit), amount(line.Credit), strconv.Itoa(line.Balance),
+		})
+	}
+	rows = append(rows, []string{report.To.Format(time.RFC3339), "", "closing balance", "", "", strconv.Itoa(report.Closing)})
+	return p.table([]string{"TIME", "ENTRY", "DESCRIPTION", "DEBIT", "CREDIT", "BALANCE"}, rows)
+}
+
+// amount leaves the empty side of a debit or credit blank
+func amount(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func (p printer) encode(value any) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, cell)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
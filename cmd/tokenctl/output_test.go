@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestPrinter_Table(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := printer{out: out}
+
+	require.NoError(t, p.wallets(
+		wallet{Address: "A", Balance: 10, Held: 2},
+		wallet{Address: "B", Owner: "bob", Balance: 5, Shards: 4, ReceiveFrozen: true},
+	))
+	require.Equal(t, ""+
+		"ADDRESS  OWNER  SEND FROZEN  RECEIVE FROZEN  BALANCE  HELD  SHARDS\n"+
+		"A               false        false           10       2     0\n"+
+		"B        bob    false        true            5        0     4\n",
+		out.String())
+
+	out.Reset()
+	require.NoError(t, p.balance("A", 10))
+	require.Equal(t, "ADDRESS  BALANCE\nA        10\n", out.String())
+}
+
+func TestPrinter_JSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := printer{json: true, out: out}
+
+	// A single wallet is printed as an object, several as an array
+	require.NoError(t, p.wallets(wallet{Address: "A", Balance: 10}))
+	require.JSONEq(t, `{"address": "A", "balance": 10, "held": 0, "shards": 0, "sendFrozen": false, "receiveFrozen": false}`, out.String())
+
+	out.Reset()
+	require.NoError(t, p.wallets(wallet{Address: "A"}, wallet{Address: "B"}))
+	require.JSONEq(t, `[
+		{"address": "A", "balance": 0, "held": 0, "shards": 0, "sendFrozen": false, "receiveFrozen": false},
+		{"address": "B", "balance": 0, "held": 0, "shards": 0, "sendFrozen": false, "receiveFrozen": false}
+	]`, out.String())
+
+	out.Reset()
+	require.NoError(t, p.balance("A", 10))
+	require.Equal(t, "{\n  \"address\": \"A\",\n  \"balance\": 10\n}\n", out.String())
+}
+
+func TestPrinter_Report(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := printer{out: out}
+
+	require.NoError(t, p.report(&service.ReconcileReport{
+		TotalSupply:      100,
+		TotalBalance:     95,
+		Discrepancies:    []service.Discrepancy{{Address: "A", Balance: 5, LedgerBalance: 10}},
+		NegativeBalances: []string{"B"},
+	}))
+	require.Equal(t, ""+
+		"CHECK             RESULT\n"+
+		"total supply      100\n"+
+		"total balance     95\n"+
+		"discrepancy A     balance 5, ledger 10\n"+
+		"negative balance  B\n"+
+		"status            FAILED\n",
+		out.String())
+}
+
+func TestPrinter_TrialBalance(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := printer{out: out}
+
+	// The empty side of a line is left blank
+	require.NoError(t, p.trialBalance(&service.TrialBalanceReport{
+		Lines: []service.TrialBalanceLine{
+			{Account: models.Account{Code: models.AccountIssuance, Type: models.AccountAsset}, Debit: 100},
+			{Account: models.WalletAccount("A"), Credit: 100},
+		},
+		Debit:  100,
+		Credit: 100,
+	}))
+	require.Equal(t, ""+
+		"ACCOUNT   TYPE       DEBIT  CREDIT\n"+
+		"issuance  ASSET      100    \n"+
+		"wallet:A  LIABILITY         100\n"+
+		"total                100    100\n",
+		out.String())
+}
+
+func TestPrinter_GeneralLedger(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := printer{out: out}
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, p.generalLedger(&service.GeneralLedgerReport{
+		Account: models.WalletAccount("A"),
+		From:    from,
+		To:      from.AddDate(0, 1, 0),
+		Opening: 10,
+		Closing: 7,
+		Lines: []service.LedgerLine{
+			{PostingID: 1, EntryID: 4, Time: from.Add(time.Hour), Description: "TRANSFER_EXECUTED", Debit: 3, Balance: 7},
+		},
+	}))
+	require.Equal(t, ""+
+		"TIME                  ENTRY  DESCRIPTION        DEBIT  CREDIT  BALANCE\n"+
+		"2026-09-01T00:00:00Z         opening balance                   10\n"+
+		"2026-09-01T01:00:00Z  4      TRANSFER_EXECUTED  3              7\n"+
+		"2026-10-01T00:00:00Z         closing balance                   7\n",
+		out.String())
+}
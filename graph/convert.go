@@ -0,0 +1,419 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"token-transfer-api/graph/model"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+// toWallet converts the database wallet into its GraphQL representation
+func toWallet(wallet *models.Wallet) *model.Wallet {
+	result := &model.Wallet{
+		Address: wallet.Address,
+		Balance: int32(wallet.Balance),
+		Held:    int32(wallet.Held),
+		Shards:  int32(wallet.Shards),
+
+		SendFrozen:    wallet.SendFrozen,
+		ReceiveFrozen: wallet.ReceiveFrozen,
+	}
+	if wallet.Owner != "" {
+		result.Owner = &wallet.Owner
+	}
+	if wallet.FreezeReason != "" {
+		result.FreezeReason = &wallet.FreezeReason
+	}
+	return result
+}
+
+// toTransfer converts the database transfer into its GraphQL representation
+func toTransfer(transfer *models.Transfer) *model.Transfer {
+	result := &model.Transfer{
+		ID:          strconv.FormatUint(uint64(transfer.ID), 10),
+		Kind:        model.TransferKind(transfer.Kind),
+		FromAddress: transfer.FromAddress,
+		ToAddress:   transfer.ToAddress,
+		Amount:      int32(transfer.Amount),
+		Fee:         int32(transfer.Fee),
+		Refunded:    int32(transfer.Refunded),
+		Status:      model.TransferStatus(transfer.Status),
+		Sequence:    transfer.Sequence,
+		CreatedAt:   transfer.CreatedAt,
+		UpdatedAt:   transfer.UpdatedAt,
+	}
+	if transfer.Reason != "" {
+		result.Reason = &transfer.Reason
+	}
+	if transfer.Spender != "" {
+		result.Spender = &transfer.Spender
+	}
+	if transfer.ParentID != nil {
+		parentID := strconv.FormatUint(uint64(*transfer.ParentID), 10)
+		result.ParentID = &parentID
+	}
+	return result
+}
+
+// toScheduledTransfer converts the database scheduled transfer into its GraphQL representation
+func toScheduledTransfer(scheduled *models.ScheduledTransfer) *model.ScheduledTransfer {
+	result := &model.ScheduledTransfer{
+		ID:          strconv.FormatUint(uint64(scheduled.ID), 10),
+		FromAddress: scheduled.FromAddress,
+		ToAddress:   scheduled.ToAddress,
+		Amount:      int32(scheduled.Amount),
+		ExecuteAt:   scheduled.ExecuteAt,
+		Status:      model.ScheduledTransferStatus(scheduled.Status),
+		CreatedAt:   scheduled.CreatedAt,
+		UpdatedAt:   scheduled.UpdatedAt,
+	}
+	if scheduled.Reason != "" {
+		result.Reason = &scheduled.Reason
+	}
+	if scheduled.TransferID != nil {
+		transferID := strconv.FormatUint(uint64(*scheduled.TransferID), 10)
+		result.TransferID = &transferID
+	}
+	return result
+}
+
+func toHold(hold *models.Hold) *model.Hold {
+	result := &model.Hold{
+		ID:             strconv.FormatUint(uint64(hold.ID), 10),
+		FromAddress:    hold.FromAddress,
+		ToAddress:      hold.ToAddress,
+		Amount:         int32(hold.Amount),
+		CapturedAmount: int32(hold.CapturedAmount),
+		ExpiresAt:      hold.ExpiresAt,
+		Status:         model.HoldStatus(hold.Status),
+		CreatedAt:      hold.CreatedAt,
+		UpdatedAt:      hold.UpdatedAt,
+	}
+	if hold.TransferID != nil {
+		transferID := strconv.FormatUint(uint64(*hold.TransferID), 10)
+		result.TransferID = &transferID
+	}
+	return result
+}
+
+func toAllowance(allowance *models.Allowance) *model.Allowance {
+	return &model.Allowance{
+		Owner:   allowance.Owner,
+		Spender: allowance.Spender,
+		Amount:  int32(allowance.Amount),
+	}
+}
+
+func toGraphFeeSchedule(schedule *models.FeeSchedule) *model.FeeSchedule {
+	result := &model.FeeSchedule{
+		Flat:        int32(schedule.Flat),
+		BasisPoints: int32(schedule.BasisPoints),
+		Tiers:       make([]*model.FeeTier, len(schedule.Tiers)),
+		MinFee:      int32(schedule.MinFee),
+	}
+	for i, tier := range schedule.Tiers {
+		result.Tiers[i] = &model.FeeTier{MinAmount: int32(tier.MinAmount), Flat: int32(tier.Flat), BasisPoints: int32(tier.BasisPoints)}
+	}
+	if schedule.Treasury != "" {
+		result.Treasury = &schedule.Treasury
+	}
+	if schedule.MaxFee > 0 {
+		maxFee := int32(schedule.MaxFee)
+		result.MaxFee = &maxFee
+	}
+	if !schedule.UpdatedAt.IsZero() {
+		result.UpdatedAt = &schedule.UpdatedAt
+	}
+	return result
+}
+
+func toFeeSchedule(input model.FeeScheduleInput) models.FeeSchedule {
+	schedule := models.FeeSchedule{
+		Treasury:    ptrValue(input.Treasury),
+		Flat:        int(ptrValue(input.Flat)),
+		BasisPoints: int(ptrValue(input.BasisPoints)),
+		Tiers:       make([]models.FeeTier, len(input.Tiers)),
+		MinFee:      int(ptrValue(input.MinFee)),
+		MaxFee:      int(ptrValue(input.MaxFee)),
+	}
+	for i, tier := range input.Tiers {
+		schedule.Tiers[i] = models.FeeTier{MinAmount: int(tier.MinAmount), Flat: int(ptrValue(tier.Flat)), BasisPoints: int(ptrValue(tier.BasisPoints))}
+	}
+	return schedule
+}
+
+func toSpendingLimit(limit *models.SpendingLimit) *model.SpendingLimit {
+	// Zero limits are not set
+	optional := func(value int) *int32 {
+		if value == 0 {
+			return nil
+		}
+		converted := int32(value)
+		return &converted
+	}
+
+	result := &model.SpendingLimit{
+		PerTransfer: optional(limit.PerTransfer),
+		Daily:       optional(limit.Daily),
+		Monthly:     optional(limit.Monthly),
+		PerMinute:   optional(limit.PerMinute),
+	}
+	if limit.Address != "" {
+		result.Address = &limit.Address
+	}
+	if !limit.UpdatedAt.IsZero() {
+		result.UpdatedAt = &limit.UpdatedAt
+	}
+	return result
+}
+
+// toFreezeDirection defaults to freezing both directions
+func toFreezeDirection(direction *model.FreezeDirection) models.FreezeDirection {
+	if direction == nil {
+		return models.FreezeBoth
+	}
+	return models.FreezeDirection(*direction)
+}
+
+func toPauseState(state *models.PauseState) *model.PauseState {
+	result := &model.PauseState{Paused: state.Paused}
+	if state.Reason != "" {
+		result.Reason = &state.Reason
+	}
+	if state.Actor != "" {
+		result.Actor = &state.Actor
+	}
+	if !state.UpdatedAt.IsZero() {
+		result.UpdatedAt = &state.UpdatedAt
+	}
+	return result
+}
+
+func toAuditRecord(record *models.AuditRecord) *model.AuditRecord {
+	result := &model.AuditRecord{
+		ID:        strconv.FormatUint(uint64(record.ID), 10),
+		Action:    model.AuditAction(record.Action),
+		Reason:    record.Reason,
+		Actor:     record.Actor,
+		CreatedAt: record.CreatedAt,
+	}
+	if record.Address != "" {
+		result.Address = &record.Address
+	}
+	if record.Direction != "" {
+		direction := model.FreezeDirection(record.Direction)
+		result.Direction = &direction
+	}
+	return result
+}
+
+func toWebhookSubscription(subscription *models.WebhookSubscription) *model.WebhookSubscription {
+	return &model.WebhookSubscription{
+		ID:         strconv.FormatUint(uint64(subscription.ID), 10),
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+func toWebhookDelivery(delivery *models.WebhookDelivery) *model.WebhookDelivery {
+	result := &model.WebhookDelivery{
+		ID:             strconv.FormatUint(uint64(delivery.ID), 10),
+		SubscriptionID: strconv.FormatUint(uint64(delivery.SubscriptionID), 10),
+		EventID:        strconv.FormatUint(uint64(delivery.EventID), 10),
+		EventType:      delivery.Event.Type,
+		Payload:        string(delivery.Event.Payload),
+		Status:         model.WebhookDeliveryStatus(delivery.Status),
+		Attempts:       int32(delivery.Attempts),
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.LastError != "" {
+		result.LastError = &delivery.LastError
+	}
+	if delivery.ResponseStatus != 0 {
+		status := int32(delivery.ResponseStatus)
+		result.ResponseStatus = &status
+	}
+	return result
+}
+
+func toAccount(account *models.Account) *model.Account {
+	result := &model.Account{Code: account.Code, Name: account.Name, Type: model.AccountType(account.Type)}
+	if account.Address != "" {
+		result.Address = &account.Address
+	}
+	return result
+}
+
+func toReconcileReport(report *service.ReconcileReport) *model.ReconcileReport {
+	result := &model.ReconcileReport{
+		Ok:               report.OK(),
+		TotalSupply:      int32(report.TotalSupply),
+		TotalBalance:     int32(report.TotalBalance),
+		Discrepancies:    make([]*model.Discrepancy, len(report.Discrepancies)),
+		NegativeBalances: report.NegativeBalances,
+	}
+	for i, d := range report.Discrepancies {
+		result.Discrepancies[i] = &model.Discrepancy{Address: d.Address, Balance: int32(d.Balance), LedgerBalance: int32(d.LedgerBalance)}
+	}
+	if result.NegativeBalances == nil {
+		result.NegativeBalances = []string{}
+	}
+	return result
+}
+
+func toLedgerLine(line *service.LedgerLine) *model.LedgerLine {
+	result := &model.LedgerLine{
+		ID:          strconv.FormatUint(uint64(line.PostingID), 10),
+		EntryID:     strconv.FormatUint(uint64(line.EntryID), 10),
+		Time:        line.Time,
+		Description: line.Description,
+		Debit:       int32(line.Debit),
+		Credit:      int32(line.Credit),
+		Balance:     int32(line.Balance),
+	}
+	if line.TransferID != nil {
+		transferID := strconv.FormatUint(uint64(*line.TransferID), 10)
+		result.TransferID = &transferID
+	}
+	if line.HoldID != nil {
+		holdID := strconv.FormatUint(uint64(*line.HoldID), 10)
+		result.HoldID = &holdID
+	}
+	return result
+}
+
+// ledgerSequence resolves the point of the ledger given either as a time or as a changes feed sequence number
+func (r *Resolver) ledgerSequence(at *time.Time, sequence *int64) (int64, error) {
+	if (at == nil) == (sequence == nil) {
+		return 0, errors.New("exactly one of at and sequence must be given")
+	}
+	if sequence != nil {
+		if *sequence < 0 {
+			return 0, errors.New("sequence must not be negative")
+		}
+		return *sequence, nil
+	}
+	return service.LedgerSequenceAt(r.DB, *at)
+}
+
+// actor is the subject recorded in the audit log for the principal of the request
+func actor(ctx context.Context) string {
+	if principal := auth.FromContext(ctx); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
+
+func toStandingOrder(order *models.StandingOrder) *model.StandingOrder {
+	result := &model.StandingOrder{
+		ID:                    strconv.FormatUint(uint64(order.ID), 10),
+		FromAddress:           order.FromAddress,
+		ToAddress:             order.ToAddress,
+		Amount:                int32(order.Amount),
+		EndAt:                 order.EndAt,
+		Runs:                  int32(order.Runs),
+		OnInsufficientBalance: model.FailurePolicy(order.OnInsufficientBalance),
+		MaxRetries:            int32(order.MaxRetries),
+		Retries:               int32(order.Retries),
+		NextRunAt:             order.NextRunAt,
+		Status:                model.StandingOrderStatus(order.Status),
+		CreatedAt:             order.CreatedAt,
+		UpdatedAt:             order.UpdatedAt,
+	}
+	if order.Cron != "" {
+		result.Cron = &order.Cron
+	} else {
+		interval := int32(order.Interval / time.Second)
+		result.IntervalSeconds = &interval
+	}
+	if order.MaxRuns > 0 {
+		maxRuns := int32(order.MaxRuns)
+		result.MaxRuns = &maxRuns
+	}
+	if order.LastError != "" {
+		result.LastError = &order.LastError
+	}
+	return result
+}
+
+func toStandingOrderSpec(input model.StandingOrderInput) service.StandingOrderSpec {
+	spec := service.StandingOrderSpec{
+		From:          input.From,
+		To:            input.To,
+		Amount:        int(input.Amount),
+		EndAt:         input.EndAt,
+		MaxRuns:       int(ptrValue(input.MaxRuns)),
+		Cron:          ptrValue(input.Cron),
+		Interval:      time.Duration(ptrValue(input.IntervalSeconds)) * time.Second,
+		MaxRetries:    int(ptrValue(input.MaxRetries)),
+		RetryInterval: time.Duration(ptrValue(input.RetryIntervalSeconds)) * time.Second,
+	}
+	if input.StartAt != nil {
+		spec.StartAt = *input.StartAt
+	}
+	if input.OnInsufficientBalance != nil {
+		spec.OnInsufficientBalance = models.FailurePolicy(*input.OnInsufficientBalance)
+	}
+	return spec
+}
+
+// ptrValue returns the value of an optional argument, or its zero value
+func ptrValue[T any](value *T) T {
+	if value == nil {
+		var zero T
+		return zero
+	}
+	return *value
+}
+
+func parseID(id string) (uint, error) {
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return uint(parsed), nil
+}
+
+// transferWallet resolves a wallet taking part in a transfer, the receiver of a pending transfer
+// may not exist yet, in that case an empty wallet is returned. Mints have no sender and burns
+// no receiver, their missing side resolves to nil.
+func (r *Resolver) transferWallet(ctx context.Context, address string) (*model.Wallet, error) {
+	if address == "" {
+		return nil, nil
+	}
+	wallet, err := r.Query().Wallet(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return &model.Wallet{Address: address}, nil
+	}
+	return wallet, nil
+}
+
+// authorizeHold parses the hold ID and checks that the principal may spend from either wallet of the hold,
+// so both the payer and the payee can capture or void it
+func (r *Resolver) authorizeHold(ctx context.Context, id string) (uint, error) {
+	holdID, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	hold, err := service.Hold(r.DB, holdID)
+	if err != nil {
+		return 0, err
+	}
+	if err := auth.AuthorizeSpend(ctx, r.DB, hold.FromAddress); err == nil || !errors.Is(err, auth.ErrForbidden) {
+		return holdID, err
+	}
+	return holdID, auth.AuthorizeSpend(ctx, r.DB, hold.ToAddress)
+}
@@ -0,0 +1,19 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"token-transfer-api/graph/model"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// hasRole resolves the field only if the principal of the request has the role
+func hasRole(ctx context.Context, _ any, next graphql.Resolver, role model.Role) (any, error) {
+	if !auth.FromContext(ctx).HasRole(models.Role(role)) {
+		return nil, fmt.Errorf("%w: %s role required", auth.ErrForbidden, role)
+	}
+	return next(ctx)
+}
@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"token-transfer-api/internal/service"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// presentError adds a machine-readable code to the errors clients are expected to handle
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	code := service.Classify(err)
+	switch code {
+	case service.CodeAddressBlocked, service.CodePaused:
+		presented.Extensions = map[string]any{"code": string(code)}
+	case service.CodeLimitExceeded:
+		var limit *service.LimitExceededError
+		errors.As(err, &limit)
+		presented.Extensions = map[string]any{
+			"code":      string(code),
+			"limit":     limit.Limit,
+			"remaining": limit.Remaining,
+		}
+	}
+	return presented
+}
@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// maxCostMultiplier caps the value of the @cost multiplier argument
+const maxCostMultiplier = 1 << 16
+
+const (
+	errDepthLimitExceeded       = "DEPTH_LIMIT_EXCEEDED"
+	errPersistedQueryNotAllowed = "PERSISTED_QUERY_NOT_ALLOWED"
+)
+
+// costSchema computes the complexity of the fields annotated with the @cost directive
+type costSchema struct {
+	graphql.ExecutableSchema
+}
+
+func (s costSchema) Complexity(ctx context.Context, typeName, fieldName string, childComplexity int, args map[string]any) (int, bool) {
+	definition := s.Schema().Types[typeName]
+	if definition == nil {
+		return s.ExecutableSchema.Complexity(ctx, typeName, fieldName, childComplexity, args)
+	}
+	field := definition.Fields.ForName(fieldName)
+	if field == nil {
+		return s.ExecutableSchema.Complexity(ctx, typeName, fieldName, childComplexity, args)
+	}
+	cost := field.Directives.ForName("cost")
+	if cost == nil {
+		return s.ExecutableSchema.Complexity(ctx, typeName, fieldName, childComplexity, args)
+	}
+
+	weight, _ := strconv.Atoi(cost.Arguments.ForName("weight").Value.Raw)
+
+	multiplier := 1
+	if argument := cost.Arguments.ForName("multiplier"); argument != nil && args[argument.Value.Raw] != nil {
+		// Cap the multiplier so huge arguments can't overflow the complexity
+		if value, err := graphql.UnmarshalInt32(args[argument.Value.Raw]); err == nil && value > 0 {
+			multiplier = min(int(value), maxCostMultiplier)
+		}
+	}
+
+	return weight + childComplexity*multiplier, true
+}
+
+// DepthLimit rejects operations with fields nested deeper than MaxDepth, introspection fields don't count
+type DepthLimit struct {
+	MaxDepth int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+func (DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (DepthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	depth := selectionDepth(opCtx.Operation.SelectionSet, map[string]bool{})
+	if depth > d.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.MaxDepth)
+		errcode.Set(err, errDepthLimitExceeded)
+		return err
+	}
+	return nil
+}
+
+// selectionDepth returns the deepest nesting of fields, fragments don't add a level on their own
+func selectionDepth(selections ast.SelectionSet, visiting map[string]bool) int {
+	depth := 0
+	for _, selection := range selections {
+		var current int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			current = 1 + selectionDepth(selection.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			current = selectionDepth(selection.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			// Cyclic fragments are rejected by validation, this only guards the recursion
+			if selection.Definition == nil || visiting[selection.Name] {
+				continue
+			}
+			visiting[selection.Name] = true
+			current = selectionDepth(selection.Definition.SelectionSet, visiting)
+			delete(visiting, selection.Name)
+		}
+		depth = max(depth, current)
+	}
+	return depth
+}
+
+// PersistedQueryAllowlist executes only the allowlisted queries, which clients refer to by their sha256
+// hash in the same persistedQuery extension used by automatic persisted queries
+type PersistedQueryAllowlist struct {
+	Queries map[string]string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = PersistedQueryAllowlist{}
+
+func (PersistedQueryAllowlist) ExtensionName() string {
+	return "PersistedQueryAllowlist"
+}
+
+func (PersistedQueryAllowlist) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a PersistedQueryAllowlist) MutateOperationParameters(_ context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	var extension struct {
+		Sha256 string `mapstructure:"sha256Hash"`
+	}
+	if rawParams.Extensions["persistedQuery"] != nil {
+		if err := mapstructure.Decode(rawParams.Extensions["persistedQuery"], &extension); err != nil {
+			return gqlerror.Errorf("invalid persisted query extension data")
+		}
+	}
+
+	// Fall back to the hash of the sent query, so allowlisted queries can also be sent in full
+	hash := extension.Sha256
+	if hash == "" && rawParams.Query != "" {
+		hash = QueryHash(rawParams.Query)
+	}
+
+	query, ok := a.Queries[hash]
+	if !ok {
+		err := gqlerror.Errorf("only allowlisted persisted queries are allowed")
+		errcode.Set(err, errPersistedQueryNotAllowed)
+		return err
+	}
+
+	rawParams.Query = query
+	return nil
+}
+
+// QueryHash returns the sha256 hash clients use to refer to a persisted query
+func QueryHash(query string) string {
+	hash := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(hash[:])
+}
+
+// LoadPersistedQueries reads the allowlist file, a JSON array of queries
+func LoadPersistedQueries(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted queries: %w", err)
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted queries: %w", err)
+	}
+
+	queries := make(map[string]string, len(list))
+	for _, query := range list {
+		queries[QueryHash(query)] = query
+	}
+	return queries, nil
+}
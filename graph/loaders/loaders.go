@@ -0,0 +1,147 @@
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"github.com/graph-gophers/dataloader/v7"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+type ctxKey struct{}
+
+// batchWait is how long a loader collects keys before running a batch query
+const batchWait = 2 * time.Millisecond
+
+// TransfersKey selects the latest transfers sent or received by a wallet
+type TransfersKey struct {
+	Address string
+	Limit   int
+}
+
+// Loaders batch the lookups made while resolving a single request into IN (...) queries
+type Loaders struct {
+	Wallet    *dataloader.Loader[string, *models.Wallet]
+	Transfers *dataloader.Loader[TransfersKey, []models.Transfer]
+}
+
+func New(db *gorm.DB) *Loaders {
+	return &Loaders{
+		Wallet:    dataloader.NewBatchedLoader(walletBatch(db), dataloader.WithWait[string, *models.Wallet](batchWait)),
+		Transfers: dataloader.NewBatchedLoader(transfersBatch(db), dataloader.WithWait[TransfersKey, []models.Transfer](batchWait)),
+	}
+}
+
+// Middleware injects new loaders into the context of every request, so nothing is cached between requests
+func Middleware(db *gorm.DB, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ctxKey{}, New(db))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// For returns the loaders of the request
+func For(ctx context.Context) *Loaders {
+	return ctx.Value(ctxKey{}).(*Loaders)
+}
+
+// Clear drops everything cached by the loaders
+func (l *Loaders) Clear() {
+	l.Wallet.ClearAll()
+	l.Transfers.ClearAll()
+}
+
+// walletBatch loads the wallets with their aggregate balance, a missing wallet resolves to nil
+func walletBatch(db *gorm.DB) dataloader.BatchFunc[string, *models.Wallet] {
+	return func(ctx context.Context, addresses []string) []*dataloader.Result[*models.Wallet] {
+		var wallets []models.Wallet
+		if err := db.WithContext(ctx).Where("address IN ?", addresses).Find(&wallets).Error; err != nil {
+			return failAll[*models.Wallet](len(addresses), fmt.Errorf("failed to load wallets: %w", err))
+		}
+
+		byAddress := make(map[string]*models.Wallet, len(wallets))
+		var sharded []string
+		for i := range wallets {
+			byAddress[wallets[i].Address] = &wallets[i]
+			if wallets[i].Shards > 0 {
+				sharded = append(sharded, wallets[i].Address)
+			}
+		}
+
+		// Add the slots of sharded wallets to their balance
+		if len(sharded) > 0 {
+			var sums []struct {
+				Address string
+				Balance int
+			}
+			err := db.WithContext(ctx).Model(&models.WalletShard{}).
+				Select("address, SUM(balance) AS balance").
+				Where("address IN ?", sharded).
+				Group("address").
+				Scan(&sums).Error
+			if err != nil {
+				return failAll[*models.Wallet](len(addresses), fmt.Errorf("failed to load wallet slots: %w", err))
+			}
+			for _, sum := range sums {
+				byAddress[sum.Address].Balance += sum.Balance
+			}
+		}
+
+		results := make([]*dataloader.Result[*models.Wallet], len(addresses))
+		for i, address := range addresses {
+			results[i] = &dataloader.Result[*models.Wallet]{Data: byAddress[address]}
+		}
+		return results
+	}
+}
+
+// transfersBatch loads the latest transfers of all requested wallets with a single window query per limit
+func transfersBatch(db *gorm.DB) dataloader.BatchFunc[TransfersKey, []models.Transfer] {
+	return func(ctx context.Context, keys []TransfersKey) []*dataloader.Result[[]models.Transfer] {
+		addressesByLimit := make(map[int][]string)
+		for _, key := range keys {
+			addressesByLimit[key.Limit] = append(addressesByLimit[key.Limit], key.Address)
+		}
+
+		byKey := make(map[TransfersKey][]models.Transfer, len(keys))
+		for limit, addresses := range addressesByLimit {
+			var rows []struct {
+				models.Transfer `gorm:"embedded"`
+				WalletAddress   string
+			}
+			err := db.WithContext(ctx).Raw(
+				"SELECT * FROM ("+
+					"SELECT transfers.*, wallets.address AS wallet_address, "+
+					"ROW_NUMBER() OVER (PARTITION BY wallets.address ORDER BY transfers.id DESC) AS position "+
+					"FROM wallets JOIN transfers ON transfers.from_address = wallets.address OR transfers.to_address = wallets.address "+
+					"WHERE wallets.address IN ?"+
+					") ranked WHERE position <= ? ORDER BY wallet_address, id DESC",
+				addresses, limit,
+			).Scan(&rows).Error
+			if err != nil {
+				return failAll[[]models.Transfer](len(keys), fmt.Errorf("failed to load transfers: %w", err))
+			}
+
+			for _, row := range rows {
+				key := TransfersKey{Address: row.WalletAddress, Limit: limit}
+				byKey[key] = append(byKey[key], row.Transfer)
+			}
+		}
+
+		results := make([]*dataloader.Result[[]models.Transfer], len(keys))
+		for i, key := range keys {
+			results[i] = &dataloader.Result[[]models.Transfer]{Data: byKey[key]}
+		}
+		return results
+	}
+}
+
+func failAll[V any](n int, err error) []*dataloader.Result[V] {
+	results := make([]*dataloader.Result[V], n)
+	for i := range results {
+		results[i] = &dataloader.Result[V]{Error: err}
+	}
+	return results
+}
@@ -0,0 +1,86 @@
+package graph_test
+
+import (
+	"fmt"
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"sync/atomic"
+	"testing"
+	"token-transfer-api/graph"
+	"token-transfer-api/graph/loaders"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+// countQueries counts every SELECT issued through GORM from now on
+func countQueries(t *testing.T, testDB *gorm.DB) *atomic.Int64 {
+	var count atomic.Int64
+	increment := func(*gorm.DB) { count.Add(1) }
+
+	require.NoError(t, testDB.Callback().Query().After("gorm:query").Register("test:count_query", increment))
+	require.NoError(t, testDB.Callback().Row().After("gorm:row").Register("test:count_row", increment))
+
+	return &count
+}
+
+func newClient(testDB *gorm.DB) *client.Client {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{DB: testDB}}))
+	srv.AddTransport(transport.POST{})
+
+	return client.New(loaders.Middleware(testDB, srv))
+}
+
+func TestWalletTransfers_BatchedQueries(t *testing.T) {
+	for _, receivers := range []int{2, 20} {
+		t.Run(fmt.Sprintf("%d receivers", receivers), func(t *testing.T) {
+			testDB := dbtest.Setup(t)
+
+			require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+			for i := 0; i < receivers; i++ {
+				_, err := service.Transfer(testDB, "A", fmt.Sprintf("R%02d", i), 10)
+				require.NoError(t, err)
+			}
+
+			count := countQueries(t, testDB)
+
+			var resp struct {
+				Wallet struct {
+					Balance   int
+					Transfers []struct {
+						From struct{ Balance int }
+						To   struct {
+							Address   string
+							Balance   int
+							Transfers []struct{ Amount int }
+						}
+					}
+				}
+			}
+			newClient(testDB).MustPost(`{
+				wallet(address: "A") {
+					balance
+					transfers {
+						from { balance }
+						to { address balance transfers(limit: 5) { amount } }
+					}
+				}
+			}`, &resp)
+
+			require.Equal(t, 1000-10*receivers, resp.Wallet.Balance)
+			require.Len(t, resp.Wallet.Transfers, receivers)
+			for _, transfer := range resp.Wallet.Transfers {
+				require.Equal(t, resp.Wallet.Balance, transfer.From.Balance)
+				require.Equal(t, 10, transfer.To.Balance)
+				require.Len(t, transfer.To.Transfers, 1)
+			}
+
+			// Sender wallet, its transfers, all receivers at once and all their transfers at once,
+			// no matter how many receivers there are
+			require.Equal(t, int64(4), count.Load())
+		})
+	}
+}
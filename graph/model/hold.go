@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Hold keeps the addresses of both wallets and the ID of the ledger entry, which are resolved on demand
+type Hold struct {
+	ID             string     `json:"id"`
+	FromAddress    string     `json:"-"`
+	ToAddress      string     `json:"-"`
+	TransferID     *string    `json:"-"`
+	Amount         int32      `json:"amount"`
+	CapturedAmount int32      `json:"capturedAmount"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	Status         HoldStatus `json:"status"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
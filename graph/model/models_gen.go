@@ -2,12 +2,861 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type Account struct {
+	Code    string      `json:"code"`
+	Name    string      `json:"name"`
+	Type    AccountType `json:"type"`
+	Address *string     `json:"address,omitempty"`
+}
+
+type Allowance struct {
+	Owner   string `json:"owner"`
+	Spender string `json:"spender"`
+	Amount  int32  `json:"amount"`
+}
+
+type AuditRecord struct {
+	ID        string           `json:"id"`
+	Action    AuditAction      `json:"action"`
+	Address   *string          `json:"address,omitempty"`
+	Direction *FreezeDirection `json:"direction,omitempty"`
+	Reason    string           `json:"reason"`
+	Actor     string           `json:"actor"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+type Discrepancy struct {
+	Address       string `json:"address"`
+	Balance       int32  `json:"balance"`
+	LedgerBalance int32  `json:"ledgerBalance"`
+}
+
+type FeeSchedule struct {
+	Treasury    *string    `json:"treasury,omitempty"`
+	Flat        int32      `json:"flat"`
+	BasisPoints int32      `json:"basisPoints"`
+	Tiers       []*FeeTier `json:"tiers"`
+	MinFee      int32      `json:"minFee"`
+	MaxFee      *int32     `json:"maxFee,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+type FeeScheduleInput struct {
+	Treasury    *string         `json:"treasury,omitempty"`
+	Flat        *int32          `json:"flat,omitempty"`
+	BasisPoints *int32          `json:"basisPoints,omitempty"`
+	Tiers       []*FeeTierInput `json:"tiers,omitempty"`
+	MinFee      *int32          `json:"minFee,omitempty"`
+	MaxFee      *int32          `json:"maxFee,omitempty"`
+}
+
+type FeeTier struct {
+	MinAmount   int32 `json:"minAmount"`
+	Flat        int32 `json:"flat"`
+	BasisPoints int32 `json:"basisPoints"`
+}
+
+type FeeTierInput struct {
+	MinAmount   int32  `json:"minAmount"`
+	Flat        *int32 `json:"flat,omitempty"`
+	BasisPoints *int32 `json:"basisPoints,omitempty"`
+}
+
+type GeneralLedger struct {
+	Account *Account      `json:"account"`
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Opening int32         `json:"opening"`
+	Closing int32         `json:"closing"`
+	Lines   []*LedgerLine `json:"lines"`
+}
+
+type LedgerLine struct {
+	ID          string    `json:"id"`
+	EntryID     string    `json:"entryId"`
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+	TransferID  *string   `json:"transferId,omitempty"`
+	HoldID      *string   `json:"holdId,omitempty"`
+	Debit       int32     `json:"debit"`
+	Credit      int32     `json:"credit"`
+	Balance     int32     `json:"balance"`
+}
+
 type Mutation struct {
 }
 
+type PauseState struct {
+	Paused    bool       `json:"paused"`
+	Reason    *string    `json:"reason,omitempty"`
+	Actor     *string    `json:"actor,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
 type Query struct {
 }
 
+type ReconcileReport struct {
+	Ok               bool           `json:"ok"`
+	TotalSupply      int32          `json:"totalSupply"`
+	TotalBalance     int32          `json:"totalBalance"`
+	Discrepancies    []*Discrepancy `json:"discrepancies"`
+	NegativeBalances []string       `json:"negativeBalances"`
+}
+
+type Snapshot struct {
+	Sequence int64            `json:"sequence"`
+	Balances []*WalletBalance `json:"balances"`
+}
+
+type SpendingLimit struct {
+	Address     *string    `json:"address,omitempty"`
+	PerTransfer *int32     `json:"perTransfer,omitempty"`
+	Daily       *int32     `json:"daily,omitempty"`
+	Monthly     *int32     `json:"monthly,omitempty"`
+	PerMinute   *int32     `json:"perMinute,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+type SpendingLimitInput struct {
+	Address     *string `json:"address,omitempty"`
+	PerTransfer *int32  `json:"perTransfer,omitempty"`
+	Daily       *int32  `json:"daily,omitempty"`
+	Monthly     *int32  `json:"monthly,omitempty"`
+	PerMinute   *int32  `json:"perMinute,omitempty"`
+}
+
+type StandingOrderInput struct {
+	From                  string         `json:"from"`
+	To                    string         `json:"to"`
+	Amount                int32          `json:"amount"`
+	Cron                  *string        `json:"cron,omitempty"`
+	IntervalSeconds       *int32         `json:"intervalSeconds,omitempty"`
+	StartAt               *time.Time     `json:"startAt,omitempty"`
+	EndAt                 *time.Time     `json:"endAt,omitempty"`
+	MaxRuns               *int32         `json:"maxRuns,omitempty"`
+	OnInsufficientBalance *FailurePolicy `json:"onInsufficientBalance,omitempty"`
+	MaxRetries            *int32         `json:"maxRetries,omitempty"`
+	RetryIntervalSeconds  *int32         `json:"retryIntervalSeconds,omitempty"`
+}
+
+type Subscription struct {
+}
+
+type TransferQuote struct {
+	Amount int32 `json:"amount"`
+	Fee    int32 `json:"fee"`
+	Total  int32 `json:"total"`
+}
+
 type TransferResult struct {
 	Balance int32 `json:"balance"`
+	Fee     int32 `json:"fee"`
+}
+
+type TrialBalance struct {
+	At     time.Time           `json:"at"`
+	Debit  int32               `json:"debit"`
+	Credit int32               `json:"credit"`
+	Lines  []*TrialBalanceLine `json:"lines"`
+}
+
+type TrialBalanceLine struct {
+	Account *Account `json:"account"`
+	Debit   int32    `json:"debit"`
+	Credit  int32    `json:"credit"`
+}
+
+type Wallet struct {
+	Address       string  `json:"address"`
+	Owner         *string `json:"owner,omitempty"`
+	SendFrozen    bool    `json:"sendFrozen"`
+	ReceiveFrozen bool    `json:"receiveFrozen"`
+	FreezeReason  *string `json:"freezeReason,omitempty"`
+	Balance       int32   `json:"balance"`
+	Held          int32   `json:"held"`
+	Shards        int32   `json:"shards"`
+}
+
+type WalletBalance struct {
+	Address string `json:"address"`
+	Balance int32  `json:"balance"`
+}
+
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	SubscriptionID string                `json:"subscriptionId"`
+	EventID        string                `json:"eventId"`
+	EventType      string                `json:"eventType"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`
+	LastError      *string               `json:"lastError,omitempty"`
+	ResponseStatus *int32                `json:"responseStatus,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "ASSET"
+	AccountTypeLiability AccountType = "LIABILITY"
+	AccountTypeRevenue   AccountType = "REVENUE"
+)
+
+var AllAccountType = []AccountType{
+	AccountTypeAsset,
+	AccountTypeLiability,
+	AccountTypeRevenue,
+}
+
+func (e AccountType) IsValid() bool {
+	switch e {
+	case AccountTypeAsset, AccountTypeLiability, AccountTypeRevenue:
+		return true
+	}
+	return false
+}
+
+func (e AccountType) String() string {
+	return string(e)
+}
+
+func (e *AccountType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountType", str)
+	}
+	return nil
+}
+
+func (e AccountType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AuditAction string
+
+const (
+	AuditActionFreeze   AuditAction = "FREEZE"
+	AuditActionUnfreeze AuditAction = "UNFREEZE"
+	AuditActionPause    AuditAction = "PAUSE"
+	AuditActionResume   AuditAction = "RESUME"
+	AuditActionReverse  AuditAction = "REVERSE"
+)
+
+var AllAuditAction = []AuditAction{
+	AuditActionFreeze,
+	AuditActionUnfreeze,
+	AuditActionPause,
+	AuditActionResume,
+	AuditActionReverse,
+}
+
+func (e AuditAction) IsValid() bool {
+	switch e {
+	case AuditActionFreeze, AuditActionUnfreeze, AuditActionPause, AuditActionResume, AuditActionReverse:
+		return true
+	}
+	return false
+}
+
+func (e AuditAction) String() string {
+	return string(e)
+}
+
+func (e *AuditAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditAction", str)
+	}
+	return nil
+}
+
+func (e AuditAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AuditAction) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AuditAction) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type FailurePolicy string
+
+const (
+	FailurePolicySkip    FailurePolicy = "SKIP"
+	FailurePolicyRetry   FailurePolicy = "RETRY"
+	FailurePolicySuspend FailurePolicy = "SUSPEND"
+)
+
+var AllFailurePolicy = []FailurePolicy{
+	FailurePolicySkip,
+	FailurePolicyRetry,
+	FailurePolicySuspend,
+}
+
+func (e FailurePolicy) IsValid() bool {
+	switch e {
+	case FailurePolicySkip, FailurePolicyRetry, FailurePolicySuspend:
+		return true
+	}
+	return false
+}
+
+func (e FailurePolicy) String() string {
+	return string(e)
+}
+
+func (e *FailurePolicy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FailurePolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FailurePolicy", str)
+	}
+	return nil
+}
+
+func (e FailurePolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FailurePolicy) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FailurePolicy) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type FreezeDirection string
+
+const (
+	FreezeDirectionSend    FreezeDirection = "SEND"
+	FreezeDirectionReceive FreezeDirection = "RECEIVE"
+	FreezeDirectionBoth    FreezeDirection = "BOTH"
+)
+
+var AllFreezeDirection = []FreezeDirection{
+	FreezeDirectionSend,
+	FreezeDirectionReceive,
+	FreezeDirectionBoth,
+}
+
+func (e FreezeDirection) IsValid() bool {
+	switch e {
+	case FreezeDirectionSend, FreezeDirectionReceive, FreezeDirectionBoth:
+		return true
+	}
+	return false
+}
+
+func (e FreezeDirection) String() string {
+	return string(e)
+}
+
+func (e *FreezeDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FreezeDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FreezeDirection", str)
+	}
+	return nil
+}
+
+func (e FreezeDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FreezeDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FreezeDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusAuthorized HoldStatus = "AUTHORIZED"
+	HoldStatusCaptured   HoldStatus = "CAPTURED"
+	HoldStatusVoided     HoldStatus = "VOIDED"
+	HoldStatusExpired    HoldStatus = "EXPIRED"
+)
+
+var AllHoldStatus = []HoldStatus{
+	HoldStatusAuthorized,
+	HoldStatusCaptured,
+	HoldStatusVoided,
+	HoldStatusExpired,
+}
+
+func (e HoldStatus) IsValid() bool {
+	switch e {
+	case HoldStatusAuthorized, HoldStatusCaptured, HoldStatusVoided, HoldStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (e HoldStatus) String() string {
+	return string(e)
+}
+
+func (e *HoldStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = HoldStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid HoldStatus", str)
+	}
+	return nil
+}
+
+func (e HoldStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *HoldStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e HoldStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Role string
+
+const (
+	RoleAdmin Role = "ADMIN"
+	RoleUser  Role = "USER"
+)
+
+var AllRole = []Role{
+	RoleAdmin,
+	RoleUser,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin, RoleUser:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusScheduled ScheduledTransferStatus = "SCHEDULED"
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "COMPLETED"
+	ScheduledTransferStatusFailed    ScheduledTransferStatus = "FAILED"
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "CANCELLED"
+)
+
+var AllScheduledTransferStatus = []ScheduledTransferStatus{
+	ScheduledTransferStatusScheduled,
+	ScheduledTransferStatusCompleted,
+	ScheduledTransferStatusFailed,
+	ScheduledTransferStatusCancelled,
+}
+
+func (e ScheduledTransferStatus) IsValid() bool {
+	switch e {
+	case ScheduledTransferStatusScheduled, ScheduledTransferStatusCompleted, ScheduledTransferStatusFailed, ScheduledTransferStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e ScheduledTransferStatus) String() string {
+	return string(e)
+}
+
+func (e *ScheduledTransferStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScheduledTransferStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScheduledTransferStatus", str)
+	}
+	return nil
+}
+
+func (e ScheduledTransferStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScheduledTransferStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScheduledTransferStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderStatusActive    StandingOrderStatus = "ACTIVE"
+	StandingOrderStatusSuspended StandingOrderStatus = "SUSPENDED"
+	StandingOrderStatusFinished  StandingOrderStatus = "FINISHED"
+	StandingOrderStatusCancelled StandingOrderStatus = "CANCELLED"
+)
+
+var AllStandingOrderStatus = []StandingOrderStatus{
+	StandingOrderStatusActive,
+	StandingOrderStatusSuspended,
+	StandingOrderStatusFinished,
+	StandingOrderStatusCancelled,
+}
+
+func (e StandingOrderStatus) IsValid() bool {
+	switch e {
+	case StandingOrderStatusActive, StandingOrderStatusSuspended, StandingOrderStatusFinished, StandingOrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e StandingOrderStatus) String() string {
+	return string(e)
+}
+
+func (e *StandingOrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StandingOrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StandingOrderStatus", str)
+	}
+	return nil
+}
+
+func (e StandingOrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StandingOrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StandingOrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TransferKind string
+
+const (
+	TransferKindTransfer TransferKind = "TRANSFER"
+	TransferKindMint     TransferKind = "MINT"
+	TransferKindBurn     TransferKind = "BURN"
+	TransferKindFee      TransferKind = "FEE"
+	TransferKindReversal TransferKind = "REVERSAL"
+	TransferKindRefund   TransferKind = "REFUND"
+)
+
+var AllTransferKind = []TransferKind{
+	TransferKindTransfer,
+	TransferKindMint,
+	TransferKindBurn,
+	TransferKindFee,
+	TransferKindReversal,
+	TransferKindRefund,
+}
+
+func (e TransferKind) IsValid() bool {
+	switch e {
+	case TransferKindTransfer, TransferKindMint, TransferKindBurn, TransferKindFee, TransferKindReversal, TransferKindRefund:
+		return true
+	}
+	return false
+}
+
+func (e TransferKind) String() string {
+	return string(e)
+}
+
+func (e *TransferKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TransferKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TransferKind", str)
+	}
+	return nil
+}
+
+func (e TransferKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TransferKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TransferKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TransferStatus string
+
+const (
+	TransferStatusPending           TransferStatus = "PENDING"
+	TransferStatusCompleted         TransferStatus = "COMPLETED"
+	TransferStatusFailed            TransferStatus = "FAILED"
+	TransferStatusReversed          TransferStatus = "REVERSED"
+	TransferStatusPartiallyRefunded TransferStatus = "PARTIALLY_REFUNDED"
+	TransferStatusRefunded          TransferStatus = "REFUNDED"
+)
+
+var AllTransferStatus = []TransferStatus{
+	TransferStatusPending,
+	TransferStatusCompleted,
+	TransferStatusFailed,
+	TransferStatusReversed,
+	TransferStatusPartiallyRefunded,
+	TransferStatusRefunded,
+}
+
+func (e TransferStatus) IsValid() bool {
+	switch e {
+	case TransferStatusPending, TransferStatusCompleted, TransferStatusFailed, TransferStatusReversed, TransferStatusPartiallyRefunded, TransferStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func (e TransferStatus) String() string {
+	return string(e)
+}
+
+func (e *TransferStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TransferStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TransferStatus", str)
+	}
+	return nil
+}
+
+func (e TransferStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TransferStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TransferStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "DEAD"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusDelivered,
+	WebhookDeliveryStatusDead,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WebhookDeliveryStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
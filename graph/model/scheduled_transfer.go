@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ScheduledTransfer keeps the addresses of both wallets and the ID of the ledger entry, which are resolved on demand
+type ScheduledTransfer struct {
+	ID          string                  `json:"id"`
+	FromAddress string                  `json:"-"`
+	ToAddress   string                  `json:"-"`
+	TransferID  *string                 `json:"-"`
+	Amount      int32                   `json:"amount"`
+	ExecuteAt   time.Time               `json:"executeAt"`
+	Status      ScheduledTransferStatus `json:"status"`
+	Reason      *string                 `json:"reason,omitempty"`
+	CreatedAt   time.Time               `json:"createdAt"`
+	UpdatedAt   time.Time               `json:"updatedAt"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// StandingOrder keeps the addresses of both wallets, which are resolved on demand
+type StandingOrder struct {
+	ID                    string              `json:"id"`
+	FromAddress           string              `json:"-"`
+	ToAddress             string              `json:"-"`
+	Amount                int32               `json:"amount"`
+	Cron                  *string             `json:"cron,omitempty"`
+	IntervalSeconds       *int32              `json:"intervalSeconds,omitempty"`
+	EndAt                 *time.Time          `json:"endAt,omitempty"`
+	MaxRuns               *int32              `json:"maxRuns,omitempty"`
+	Runs                  int32               `json:"runs"`
+	OnInsufficientBalance FailurePolicy       `json:"onInsufficientBalance"`
+	MaxRetries            int32               `json:"maxRetries"`
+	Retries               int32               `json:"retries"`
+	NextRunAt             time.Time           `json:"nextRunAt"`
+	Status                StandingOrderStatus `json:"status"`
+	LastError             *string             `json:"lastError,omitempty"`
+	CreatedAt             time.Time           `json:"createdAt"`
+	UpdatedAt             time.Time           `json:"updatedAt"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Transfer keeps the addresses of both wallets, the wallets themselves are resolved on demand
+type Transfer struct {
+	ID          string         `json:"id"`
+	Kind        TransferKind   `json:"kind"`
+	FromAddress string         `json:"-"`
+	ToAddress   string         `json:"-"`
+	Amount      int32          `json:"amount"`
+	Fee         int32          `json:"fee"`
+	Status      TransferStatus `json:"status"`
+	Reason      *string        `json:"reason,omitempty"`
+	Spender     *string        `json:"spender,omitempty"`
+	Refunded    int32          `json:"refunded"`
+	ParentID    *string        `json:"-"`
+	Sequence    *int64         `json:"sequence,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
@@ -1,7 +1,14 @@
 package graph
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"token-transfer-api/internal/service"
+)
+
+// maxTransfersLimit caps the number of transfers returned for a single wallet
+const maxTransfersLimit = 500
 
 type Resolver struct {
-	DB *gorm.DB
+	DB    *gorm.DB
+	Queue *service.Queue
 }
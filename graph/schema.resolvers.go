@@ -6,31 +6,1003 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+	"token-transfer-api/graph/loaders"
 	"token-transfer-api/graph/model"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
 	"token-transfer-api/internal/service"
+
+	"gorm.io/gorm"
 )
 
+// From is the resolver for the from field.
+func (r *holdResolver) From(ctx context.Context, obj *model.Hold) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.FromAddress)
+}
+
+// To is the resolver for the to field.
+func (r *holdResolver) To(ctx context.Context, obj *model.Hold) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.ToAddress)
+}
+
+// Transfer is the resolver for the transfer field.
+func (r *holdResolver) Transfer(ctx context.Context, obj *model.Hold) (*model.Transfer, error) {
+	if obj.TransferID == nil {
+		return nil, nil
+	}
+	return r.Query().Transfer(ctx, *obj.TransferID)
+}
+
 // Transfer mutation handling using service logic
-func (r *mutationResolver) Transfer(_ context.Context, from string, to string, amount int32) (*model.TransferResult, error) {
-	newBalance, err := service.Transfer(r.DB, from, to, int(amount))
+func (r *mutationResolver) Transfer(ctx context.Context, from string, to string, amount int32) (*model.TransferResult, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, from); err != nil {
+		return nil, fmt.Errorf("transfer failed: %w", err)
+	}
+
+	entry, newBalance, err := service.TransferRecorded(r.DB, from, to, int(amount))
 	if err != nil {
 		return nil, fmt.Errorf("transfer failed: %w", err)
 	}
 
+	return &model.TransferResult{Balance: int32(newBalance), Fee: int32(entry.Fee)}, nil
+}
+
+// SubmitTransfer is the resolver for the submitTransfer field.
+func (r *mutationResolver) SubmitTransfer(ctx context.Context, from string, to string, amount int32) (*model.Transfer, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, from); err != nil {
+		return nil, fmt.Errorf("submit transfer failed: %w", err)
+	}
+
+	transfer, err := r.Queue.Submit(ctx, from, to, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("submit transfer failed: %w", err)
+	}
+
+	return toTransfer(transfer), nil
+}
+
+// ScheduleTransfer is the resolver for the scheduleTransfer field.
+func (r *mutationResolver) ScheduleTransfer(ctx context.Context, from string, to string, amount int32, executeAt time.Time) (*model.ScheduledTransfer, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, from); err != nil {
+		return nil, fmt.Errorf("schedule transfer failed: %w", err)
+	}
+
+	scheduled, err := service.ScheduleTransfer(r.DB, from, to, int(amount), executeAt)
+	if err != nil {
+		return nil, fmt.Errorf("schedule transfer failed: %w", err)
+	}
+
+	return toScheduledTransfer(scheduled), nil
+}
+
+// CancelScheduledTransfer is the resolver for the cancelScheduledTransfer field.
+func (r *mutationResolver) CancelScheduledTransfer(ctx context.Context, id string) (*model.ScheduledTransfer, error) {
+	scheduledID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whoever may spend from the sender may cancel the transfer
+	scheduled, err := service.ScheduledTransfer(r.DB, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel scheduled transfer failed: %w", err)
+	}
+	if err := auth.AuthorizeSpend(ctx, r.DB, scheduled.FromAddress); err != nil {
+		return nil, fmt.Errorf("cancel scheduled transfer failed: %w", err)
+	}
+
+	scheduled, err = service.CancelScheduledTransfer(r.DB, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel scheduled transfer failed: %w", err)
+	}
+
+	return toScheduledTransfer(scheduled), nil
+}
+
+// CreateStandingOrder is the resolver for the createStandingOrder field.
+func (r *mutationResolver) CreateStandingOrder(ctx context.Context, input model.StandingOrderInput) (*model.StandingOrder, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, input.From); err != nil {
+		return nil, fmt.Errorf("create standing order failed: %w", err)
+	}
+
+	order, err := service.CreateStandingOrder(r.DB, toStandingOrderSpec(input))
+	if err != nil {
+		return nil, fmt.Errorf("create standing order failed: %w", err)
+	}
+
+	return toStandingOrder(order), nil
+}
+
+// CancelStandingOrder is the resolver for the cancelStandingOrder field.
+func (r *mutationResolver) CancelStandingOrder(ctx context.Context, id string) (*model.StandingOrder, error) {
+	orderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whoever may spend from the sender may cancel the order
+	order, err := service.StandingOrder(r.DB, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel standing order failed: %w", err)
+	}
+	if err := auth.AuthorizeSpend(ctx, r.DB, order.FromAddress); err != nil {
+		return nil, fmt.Errorf("cancel standing order failed: %w", err)
+	}
+
+	order, err = service.CancelStandingOrder(r.DB, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel standing order failed: %w", err)
+	}
+
+	return toStandingOrder(order), nil
+}
+
+// ResumeStandingOrder is the resolver for the resumeStandingOrder field.
+func (r *mutationResolver) ResumeStandingOrder(ctx context.Context, id string) (*model.StandingOrder, error) {
+	orderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := service.StandingOrder(r.DB, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("resume standing order failed: %w", err)
+	}
+	if err := auth.AuthorizeSpend(ctx, r.DB, order.FromAddress); err != nil {
+		return nil, fmt.Errorf("resume standing order failed: %w", err)
+	}
+
+	order, err = service.ResumeStandingOrder(r.DB, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("resume standing order failed: %w", err)
+	}
+
+	return toStandingOrder(order), nil
+}
+
+// AuthorizeTransfer is the resolver for the authorizeTransfer field.
+func (r *mutationResolver) AuthorizeTransfer(ctx context.Context, from string, to string, amount int32, expiresAt *time.Time) (*model.Hold, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, from); err != nil {
+		return nil, fmt.Errorf("authorize transfer failed: %w", err)
+	}
+
+	var expires time.Time
+	if expiresAt != nil {
+		expires = *expiresAt
+	}
+	hold, err := service.AuthorizeTransfer(r.DB, from, to, int(amount), expires)
+	if err != nil {
+		return nil, fmt.Errorf("authorize transfer failed: %w", err)
+	}
+
+	return toHold(hold), nil
+}
+
+// CaptureTransfer is the resolver for the captureTransfer field.
+func (r *mutationResolver) CaptureTransfer(ctx context.Context, id string, amount *int32) (*model.Hold, error) {
+	holdID, err := r.authorizeHold(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("capture transfer failed: %w", err)
+	}
+
+	var captured int
+	if amount != nil {
+		captured = int(*amount)
+		if captured <= 0 {
+			return nil, fmt.Errorf("capture transfer failed: capture amount must be greater than 0")
+		}
+	}
+	hold, err := service.CaptureTransfer(r.DB, holdID, captured)
+	if err != nil {
+		return nil, fmt.Errorf("capture transfer failed: %w", err)
+	}
+
+	return toHold(hold), nil
+}
+
+// VoidTransfer is the resolver for the voidTransfer field.
+func (r *mutationResolver) VoidTransfer(ctx context.Context, id string) (*model.Hold, error) {
+	holdID, err := r.authorizeHold(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("void transfer failed: %w", err)
+	}
+
+	hold, err := service.VoidTransfer(r.DB, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("void transfer failed: %w", err)
+	}
+
+	return toHold(hold), nil
+}
+
+// Approve is the resolver for the approve field.
+func (r *mutationResolver) Approve(ctx context.Context, owner string, spender string, amount int32) (*model.Allowance, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, owner); err != nil {
+		return nil, fmt.Errorf("approve failed: %w", err)
+	}
+
+	allowance, err := service.Approve(r.DB, owner, spender, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("approve failed: %w", err)
+	}
+
+	return toAllowance(allowance), nil
+}
+
+// IncreaseAllowance is the resolver for the increaseAllowance field.
+func (r *mutationResolver) IncreaseAllowance(ctx context.Context, owner string, spender string, amount int32) (*model.Allowance, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, owner); err != nil {
+		return nil, fmt.Errorf("increase allowance failed: %w", err)
+	}
+
+	allowance, err := service.IncreaseAllowance(r.DB, owner, spender, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("increase allowance failed: %w", err)
+	}
+
+	return toAllowance(allowance), nil
+}
+
+// DecreaseAllowance is the resolver for the decreaseAllowance field.
+func (r *mutationResolver) DecreaseAllowance(ctx context.Context, owner string, spender string, amount int32) (*model.Allowance, error) {
+	if err := auth.AuthorizeSpend(ctx, r.DB, owner); err != nil {
+		return nil, fmt.Errorf("decrease allowance failed: %w", err)
+	}
+
+	allowance, err := service.DecreaseAllowance(r.DB, owner, spender, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("decrease allowance failed: %w", err)
+	}
+
+	return toAllowance(allowance), nil
+}
+
+// TransferFrom is the resolver for the transferFrom field.
+func (r *mutationResolver) TransferFrom(ctx context.Context, spender string, from string, to string, amount int32) (*model.TransferResult, error) {
+	// The allowance is granted to the spender, so the spender's owner moves the tokens
+	if err := auth.AuthorizeSpend(ctx, r.DB, spender); err != nil {
+		return nil, fmt.Errorf("transfer from failed: %w", err)
+	}
+
+	entry, newBalance, err := service.TransferFrom(r.DB, spender, from, to, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("transfer from failed: %w", err)
+	}
+
+	return &model.TransferResult{Balance: int32(newBalance), Fee: int32(entry.Fee)}, nil
+}
+
+// ReverseTransfer is the resolver for the reverseTransfer field.
+func (r *mutationResolver) ReverseTransfer(ctx context.Context, id string, reason string) (*model.Transfer, error) {
+	transferID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal, err := service.ReverseTransfer(r.DB, transferID, reason, actor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("reverse transfer failed: %w", err)
+	}
+
+	return toTransfer(reversal), nil
+}
+
+// Refund is the resolver for the refund field.
+func (r *mutationResolver) Refund(ctx context.Context, id string, amount *int32) (*model.Transfer, error) {
+	transferID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Only a missing amount refunds everything
+	if amount != nil && *amount < 1 {
+		return nil, fmt.Errorf("refund failed: refund amount must be greater than 0")
+	}
+
+	// Only the receiver may send the tokens back
+	var transfer models.Transfer
+	if err := r.DB.First(&transfer, transferID).Error; err != nil {
+		return nil, fmt.Errorf("refund failed: transfer not found: %w", err)
+	}
+	if err := auth.AuthorizeSpend(ctx, r.DB, transfer.ToAddress); err != nil {
+		return nil, fmt.Errorf("refund failed: %w", err)
+	}
+
+	var refund *models.Transfer
+	if amount == nil {
+		refund, err = service.RefundRemaining(r.DB, transferID)
+	} else {
+		refund, err = service.Refund(r.DB, transferID, int(*amount))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("refund failed: %w", err)
+	}
+
+	return toTransfer(refund), nil
+}
+
+// EnableSharding is the resolver for the enableSharding field.
+func (r *mutationResolver) EnableSharding(_ context.Context, address string, slots int32) (*model.Wallet, error) {
+	wallet, err := service.EnableSharding(r.DB, address, int(slots))
+	if err != nil {
+		return nil, fmt.Errorf("enable sharding failed: %w", err)
+	}
+
+	return toWallet(wallet), nil
+}
+
+// DisableSharding is the resolver for the disableSharding field.
+func (r *mutationResolver) DisableSharding(_ context.Context, address string) (*model.Wallet, error) {
+	wallet, err := service.DisableSharding(r.DB, address)
+	if err != nil {
+		return nil, fmt.Errorf("disable sharding failed: %w", err)
+	}
+
+	return toWallet(wallet), nil
+}
+
+// RebalanceShards is the resolver for the rebalanceShards field.
+func (r *mutationResolver) RebalanceShards(_ context.Context, address string) (*model.Wallet, error) {
+	wallet, err := service.RebalanceShards(r.DB, address)
+	if err != nil {
+		return nil, fmt.Errorf("rebalance failed: %w", err)
+	}
+
+	return toWallet(wallet), nil
+}
+
+// Mint is the resolver for the mint field.
+func (r *mutationResolver) Mint(_ context.Context, to string, amount int32) (*model.TransferResult, error) {
+	newBalance, err := service.Mint(r.DB, to, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("mint failed: %w", err)
+	}
+
 	return &model.TransferResult{Balance: int32(newBalance)}, nil
 }
 
-// Empty is the resolver for the _empty field.
-func (r *queryResolver) Empty(_ context.Context) (*string, error) {
-	panic(fmt.Errorf("not implemented: Empty - _empty"))
+// Burn is the resolver for the burn field.
+func (r *mutationResolver) Burn(_ context.Context, from string, amount int32) (*model.TransferResult, error) {
+	newBalance, err := service.Burn(r.DB, from, int(amount))
+	if err != nil {
+		return nil, fmt.Errorf("burn failed: %w", err)
+	}
+
+	return &model.TransferResult{Balance: int32(newBalance)}, nil
+}
+
+// SetWalletOwner is the resolver for the setWalletOwner field.
+func (r *mutationResolver) SetWalletOwner(_ context.Context, address string, owner string) (*model.Wallet, error) {
+	if err := auth.SetWalletOwner(r.DB, address, owner); err != nil {
+		return nil, fmt.Errorf("set wallet owner failed: %w", err)
+	}
+
+	wallet, err := service.Wallet(r.DB, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return toWallet(wallet), nil
+}
+
+// FreezeWallet is the resolver for the freezeWallet field.
+func (r *mutationResolver) FreezeWallet(ctx context.Context, address string, direction *model.FreezeDirection, reason string) (*model.Wallet, error) {
+	wallet, err := service.FreezeWallet(r.DB, address, toFreezeDirection(direction), reason, actor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("freeze wallet failed: %w", err)
+	}
+
+	return toWallet(wallet), nil
+}
+
+// UnfreezeWallet is the resolver for the unfreezeWallet field.
+func (r *mutationResolver) UnfreezeWallet(ctx context.Context, address string, direction *model.FreezeDirection, reason string) (*model.Wallet, error) {
+	wallet, err := service.UnfreezeWallet(r.DB, address, toFreezeDirection(direction), reason, actor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("unfreeze wallet failed: %w", err)
+	}
+
+	return toWallet(wallet), nil
+}
+
+// Pause is the resolver for the pause field.
+func (r *mutationResolver) Pause(ctx context.Context, reason string) (*model.PauseState, error) {
+	state, err := service.Pause(r.DB, reason, actor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("pause failed: %w", err)
+	}
+
+	return toPauseState(state), nil
+}
+
+// Resume is the resolver for the resume field.
+func (r *mutationResolver) Resume(ctx context.Context, reason string) (*model.PauseState, error) {
+	state, err := service.Resume(r.DB, reason, actor(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("resume failed: %w", err)
+	}
+
+	return toPauseState(state), nil
+}
+
+// RecordReconcile is the resolver for the recordReconcile field.
+func (r *mutationResolver) RecordReconcile(_ context.Context, strict *bool) (*model.ReconcileReport, error) {
+	run, err := service.RecordReconcile(r.DB, ptrValue(strict))
+	if err != nil {
+		return nil, err
+	}
+
+	return toReconcileReport(&service.ReconcileReport{
+		TotalSupply:      run.TotalSupply,
+		TotalBalance:     run.TotalBalance,
+		Discrepancies:    run.Discrepancies,
+		NegativeBalances: run.NegativeBalances,
+	}), nil
+}
+
+// SetFeeSchedule is the resolver for the setFeeSchedule field.
+func (r *mutationResolver) SetFeeSchedule(_ context.Context, input model.FeeScheduleInput) (*model.FeeSchedule, error) {
+	schedule, err := service.SetFeeSchedule(r.DB, toFeeSchedule(input))
+	if err != nil {
+		return nil, fmt.Errorf("set fee schedule failed: %w", err)
+	}
+
+	return toGraphFeeSchedule(schedule), nil
 }
 
+// SetSpendingLimit is the resolver for the setSpendingLimit field.
+func (r *mutationResolver) SetSpendingLimit(_ context.Context, input model.SpendingLimitInput) (*model.SpendingLimit, error) {
+	limit, err := service.SetSpendingLimit(r.DB, models.SpendingLimit{
+		Address:     ptrValue(input.Address),
+		PerTransfer: int(ptrValue(input.PerTransfer)),
+		Daily:       int(ptrValue(input.Daily)),
+		Monthly:     int(ptrValue(input.Monthly)),
+		PerMinute:   int(ptrValue(input.PerMinute)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("set spending limit failed: %w", err)
+	}
+
+	return toSpendingLimit(limit), nil
+}
+
+// CreateAPIKey is the resolver for the createApiKey field.
+func (r *mutationResolver) CreateAPIKey(_ context.Context, name string, subject string, role model.Role) (string, error) {
+	key, err := auth.CreateAPIKey(r.DB, name, subject, models.Role(role))
+	if err != nil {
+		return "", fmt.Errorf("create API key failed: %w", err)
+	}
+
+	return key, nil
+}
+
+// CreateWebhookSubscription is the resolver for the createWebhookSubscription field.
+func (r *mutationResolver) CreateWebhookSubscription(_ context.Context, url string, eventTypes []string, secret string) (*model.WebhookSubscription, error) {
+	subscription, err := service.CreateWebhookSubscription(r.DB, url, eventTypes, secret)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookSubscription(subscription), nil
+}
+
+// DeleteWebhookSubscription is the resolver for the deleteWebhookSubscription field.
+func (r *mutationResolver) DeleteWebhookSubscription(_ context.Context, id string) (*model.WebhookSubscription, error) {
+	subscriptionID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := service.DeleteWebhookSubscription(r.DB, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookSubscription(subscription), nil
+}
+
+// ReplayWebhookDelivery is the resolver for the replayWebhookDelivery field.
+func (r *mutationResolver) ReplayWebhookDelivery(_ context.Context, id string) (*model.WebhookDelivery, error) {
+	deliveryID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := service.ReplayWebhookDelivery(r.DB, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDelivery(delivery), nil
+}
+
+// Wallet is the resolver for the wallet field.
+func (r *queryResolver) Wallet(ctx context.Context, address string) (*model.Wallet, error) {
+	wallet, err := loaders.For(ctx).Wallet.Load(ctx, address)()
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, nil
+	}
+
+	return toWallet(wallet), nil
+}
+
+// Snapshot is the resolver for the snapshot field.
+func (r *queryResolver) Snapshot(_ context.Context, at *time.Time, sequence *int64, after *string, limit *int32) (*model.Snapshot, error) {
+	ledgerSequence, err := r.ledgerSequence(at, sequence)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	balances, err := service.Snapshot(r.DB, ledgerSequence, ptrValue(after), int(*limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.Snapshot{
+		Sequence: ledgerSequence,
+		Balances: make([]*model.WalletBalance, len(balances)),
+	}
+	for i, balance := range balances {
+		result.Balances[i] = &model.WalletBalance{Address: balance.Address, Balance: int32(balance.Balance)}
+	}
+	return result, nil
+}
+
+// Wallets is the resolver for the wallets field.
+func (r *queryResolver) Wallets(_ context.Context, after *string, limit *int32) ([]*model.Wallet, error) {
+	wallets, err := service.ListWallets(r.DB, ptrValue(after), int(ptrValue(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Wallet, len(wallets))
+	for i := range wallets {
+		result[i] = toWallet(&wallets[i])
+	}
+	return result, nil
+}
+
+// Transfer is the resolver for the transfer field.
+func (r *queryResolver) Transfer(_ context.Context, id string) (*model.Transfer, error) {
+	transferID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer models.Transfer
+	if err := r.DB.First(&transfer, transferID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load transfer: %w", err)
+	}
+
+	return toTransfer(&transfer), nil
+}
+
+// ScheduledTransfer is the resolver for the scheduledTransfer field.
+func (r *queryResolver) ScheduledTransfer(_ context.Context, id string) (*model.ScheduledTransfer, error) {
+	scheduledID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := service.ScheduledTransfer(r.DB, scheduledID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toScheduledTransfer(scheduled), nil
+}
+
+// StandingOrder is the resolver for the standingOrder field.
+func (r *queryResolver) StandingOrder(_ context.Context, id string) (*model.StandingOrder, error) {
+	orderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := service.StandingOrder(r.DB, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toStandingOrder(order), nil
+}
+
+// Hold is the resolver for the hold field.
+func (r *queryResolver) Hold(_ context.Context, id string) (*model.Hold, error) {
+	holdID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := service.Hold(r.DB, holdID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toHold(hold), nil
+}
+
+// Allowance is the resolver for the allowance field.
+func (r *queryResolver) Allowance(_ context.Context, owner string, spender string) (int32, error) {
+	allowance, err := service.Allowance(r.DB, owner, spender)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(allowance), nil
+}
+
+// QuoteTransfer is the resolver for the quoteTransfer field.
+func (r *queryResolver) QuoteTransfer(_ context.Context, from string, to string, amount int32) (*model.TransferQuote, error) {
+	fee, err := service.QuoteFee(r.DB, from, int(amount))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TransferQuote{Amount: amount, Fee: int32(fee), Total: amount + int32(fee)}, nil
+}
+
+// FeeSchedule is the resolver for the feeSchedule field.
+func (r *queryResolver) FeeSchedule(_ context.Context) (*model.FeeSchedule, error) {
+	schedule, err := service.FeeSchedule(r.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGraphFeeSchedule(schedule), nil
+}
+
+// SpendingLimit is the resolver for the spendingLimit field.
+func (r *queryResolver) SpendingLimit(_ context.Context, address *string) (*model.SpendingLimit, error) {
+	limit, err := service.SpendingLimit(r.DB, ptrValue(address))
+	if err != nil {
+		return nil, err
+	}
+
+	return toSpendingLimit(limit), nil
+}
+
+// PauseState is the resolver for the pauseState field.
+func (r *queryResolver) PauseState(_ context.Context) (*model.PauseState, error) {
+	state, err := service.PauseState(r.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPauseState(state), nil
+}
+
+// Changes is the resolver for the changes field.
+func (r *queryResolver) Changes(_ context.Context, since *int64, limit *int32) ([]*model.Transfer, error) {
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	transfers, err := service.Changes(r.DB, "", ptrValue(since), int(*limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Transfer, len(transfers))
+	for i := range transfers {
+		result[i] = toTransfer(&transfers[i])
+	}
+	return result, nil
+}
+
+// AuditLog is the resolver for the auditLog field.
+func (r *queryResolver) AuditLog(_ context.Context, address *string, limit *int32) ([]*model.AuditRecord, error) {
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	records, err := service.AuditLog(r.DB, ptrValue(address), int(*limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.AuditRecord, len(records))
+	for i := range records {
+		result[i] = toAuditRecord(&records[i])
+	}
+	return result, nil
+}
+
+// WebhookSubscriptions is the resolver for the webhookSubscriptions field.
+func (r *queryResolver) WebhookSubscriptions(_ context.Context) ([]*model.WebhookSubscription, error) {
+	subscriptions, err := service.WebhookSubscriptions(r.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.WebhookSubscription, len(subscriptions))
+	for i := range subscriptions {
+		result[i] = toWebhookSubscription(&subscriptions[i])
+	}
+	return result, nil
+}
+
+// Accounts is the resolver for the accounts field.
+func (r *queryResolver) Accounts(_ context.Context, after *string, limit *int32) ([]*model.Account, error) {
+	accounts, err := service.Accounts(r.DB, ptrValue(after), int(ptrValue(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Account, len(accounts))
+	for i := range accounts {
+		result[i] = toAccount(&accounts[i])
+	}
+	return result, nil
+}
+
+// TrialBalance is the resolver for the trialBalance field.
+func (r *queryResolver) TrialBalance(_ context.Context, at *time.Time, after *string, limit *int32) (*model.TrialBalance, error) {
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	report, err := service.TrialBalance(r.DB, *at, ptrValue(after), int(ptrValue(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.TrialBalance{
+		At:     report.At,
+		Debit:  int32(report.Debit),
+		Credit: int32(report.Credit),
+		Lines:  make([]*model.TrialBalanceLine, len(report.Lines)),
+	}
+	for i, line := range report.Lines {
+		result.Lines[i] = &model.TrialBalanceLine{Account: toAccount(&line.Account), Debit: int32(line.Debit), Credit: int32(line.Credit)}
+	}
+	return result, nil
+}
+
+// GeneralLedger is the resolver for the generalLedger field.
+func (r *queryResolver) GeneralLedger(_ context.Context, account string, from time.Time, to time.Time, after *string, limit *int32) (*model.GeneralLedger, error) {
+	var afterID uint
+	if after != nil {
+		var err error
+		if afterID, err = parseID(*after); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := service.GeneralLedger(r.DB, account, from, to, afterID, int(ptrValue(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.GeneralLedger{
+		Account: toAccount(&report.Account),
+		From:    report.From,
+		To:      report.To,
+		Opening: int32(report.Opening),
+		Closing: int32(report.Closing),
+		Lines:   make([]*model.LedgerLine, len(report.Lines)),
+	}
+	for i := range report.Lines {
+		result.Lines[i] = toLedgerLine(&report.Lines[i])
+	}
+	return result, nil
+}
+
+// Reconcile is the resolver for the reconcile field.
+func (r *queryResolver) Reconcile(_ context.Context) (*model.ReconcileReport, error) {
+	report, err := service.Reconcile(r.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReconcileReport(report), nil
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(_ context.Context, subscriptionID *string, status *model.WebhookDeliveryStatus, limit *int32) ([]*model.WebhookDelivery, error) {
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	var subscription *uint
+	if subscriptionID != nil {
+		id, err := parseID(*subscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		subscription = &id
+	}
+
+	deliveries, err := service.WebhookDeliveries(r.DB, subscription, models.WebhookDeliveryStatus(ptrValue(status)), int(*limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		result[i] = toWebhookDelivery(&deliveries[i])
+	}
+	return result, nil
+}
+
+// From is the resolver for the from field.
+func (r *scheduledTransferResolver) From(ctx context.Context, obj *model.ScheduledTransfer) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.FromAddress)
+}
+
+// To is the resolver for the to field.
+func (r *scheduledTransferResolver) To(ctx context.Context, obj *model.ScheduledTransfer) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.ToAddress)
+}
+
+// Transfer is the resolver for the transfer field.
+func (r *scheduledTransferResolver) Transfer(ctx context.Context, obj *model.ScheduledTransfer) (*model.Transfer, error) {
+	if obj.TransferID == nil {
+		return nil, nil
+	}
+	return r.Query().Transfer(ctx, *obj.TransferID)
+}
+
+// From is the resolver for the from field.
+func (r *standingOrderResolver) From(ctx context.Context, obj *model.StandingOrder) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.FromAddress)
+}
+
+// To is the resolver for the to field.
+func (r *standingOrderResolver) To(ctx context.Context, obj *model.StandingOrder) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.ToAddress)
+}
+
+// Transfers is the resolver for the transfers field.
+func (r *standingOrderResolver) Transfers(_ context.Context, obj *model.StandingOrder, limit *int32) ([]*model.Transfer, error) {
+	orderID, err := parseID(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	runs, err := service.StandingOrderRuns(r.DB, orderID, int(*limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Transfer, len(runs))
+	for i := range runs {
+		result[i] = toTransfer(&runs[i])
+	}
+	return result, nil
+}
+
+// TransferStatus is the resolver for the transferStatus field.
+func (r *subscriptionResolver) TransferStatus(ctx context.Context, id string) (<-chan *model.Transfer, error) {
+	transferID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, cancel, err := r.Queue.Subscribe(transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *model.Transfer, 1)
+	go func() {
+		defer close(ch)
+		defer cancel()
+
+		select {
+		case transfer := <-updates:
+			// The loaders live as long as the websocket connection, drop wallets cached before the update
+			loaders.For(ctx).Clear()
+			ch <- toTransfer(&transfer)
+		case <-ctx.Done():
+		}
+	}()
+
+	return ch, nil
+}
+
+// From is the resolver for the from field.
+func (r *transferResolver) From(ctx context.Context, obj *model.Transfer) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.FromAddress)
+}
+
+// To is the resolver for the to field.
+func (r *transferResolver) To(ctx context.Context, obj *model.Transfer) (*model.Wallet, error) {
+	return r.transferWallet(ctx, obj.ToAddress)
+}
+
+// Parent is the resolver for the parent field.
+func (r *transferResolver) Parent(ctx context.Context, obj *model.Transfer) (*model.Transfer, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	return r.Query().Transfer(ctx, *obj.ParentID)
+}
+
+// Transfers is the resolver for the transfers field.
+func (r *walletResolver) Transfers(ctx context.Context, obj *model.Wallet, limit *int32) ([]*model.Transfer, error) {
+	if limit == nil || *limit < 1 || *limit > maxTransfersLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxTransfersLimit)
+	}
+
+	transfers, err := loaders.For(ctx).Transfers.Load(ctx, loaders.TransfersKey{Address: obj.Address, Limit: int(*limit)})()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Transfer, len(transfers))
+	for i := range transfers {
+		result[i] = toTransfer(&transfers[i])
+	}
+	return result, nil
+}
+
+// BalanceAt is the resolver for the balanceAt field.
+func (r *walletResolver) BalanceAt(_ context.Context, obj *model.Wallet, at *time.Time, sequence *int64) (int32, error) {
+	ledgerSequence, err := r.ledgerSequence(at, sequence)
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := service.BalanceAt(r.DB, obj.Address, ledgerSequence)
+	if err != nil {
+		return 0, err
+	}
+	return int32(balance), nil
+}
+
+// Hold returns HoldResolver implementation.
+func (r *Resolver) Hold() HoldResolver { return &holdResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// ScheduledTransfer returns ScheduledTransferResolver implementation.
+func (r *Resolver) ScheduledTransfer() ScheduledTransferResolver {
+	return &scheduledTransferResolver{r}
+}
+
+// StandingOrder returns StandingOrderResolver implementation.
+func (r *Resolver) StandingOrder() StandingOrderResolver { return &standingOrderResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Transfer returns TransferResolver implementation.
+func (r *Resolver) Transfer() TransferResolver { return &transferResolver{r} }
+
+// Wallet returns WalletResolver implementation.
+func (r *Resolver) Wallet() WalletResolver { return &walletResolver{r} }
+
+type holdResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type scheduledTransferResolver struct{ *Resolver }
+type standingOrderResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type transferResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// ServerConfig limits what clients are allowed to send to the GraphQL server
+type ServerConfig struct {
+	// ComplexityLimit is the maximum total @cost of a single operation
+	ComplexityLimit int
+	// MaxDepth is the maximum nesting of fields in a single operation
+	MaxDepth int
+	// APQCacheSize is the number of automatic persisted queries kept in the LRU cache
+	APQCacheSize int
+	// Introspection enables the introspection queries used by the GraphQL Playground
+	Introspection bool
+	// PersistedQueries are the allowlisted queries by their sha256 hash. When set, only
+	// these queries are executed and automatic persisted queries are disabled.
+	PersistedQueries map[string]string
+}
+
+// DefaultServerConfig is used for every zero limit of the ServerConfig passed to NewServer
+var DefaultServerConfig = ServerConfig{
+	ComplexityLimit: 1000,
+	MaxDepth:        10,
+	APQCacheSize:    1000,
+}
+
+// NewServer sets up the GraphQL server with resolvers, transports and query limits
+func NewServer(resolver *Resolver, config ServerConfig) *handler.Server {
+	if config.ComplexityLimit <= 0 {
+		config.ComplexityLimit = DefaultServerConfig.ComplexityLimit
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = DefaultServerConfig.MaxDepth
+	}
+	if config.APQCacheSize <= 0 {
+		config.APQCacheSize = DefaultServerConfig.APQCacheSize
+	}
+
+	schema := costSchema{NewExecutableSchema(Config{
+		Resolvers:  resolver,
+		Directives: DirectiveRoot{HasRole: hasRole},
+	})}
+	srv := handler.New(schema)
+	srv.SetErrorPresenter(presentError)
+
+	// Enable standard HTTP transports (OPTIONS, GET, POST) and websockets for subscriptions
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+
+	// Enable introspection for the GraphQL Playground
+	if config.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+
+	// Allow either only the allowlisted queries or any query, cached by its hash
+	if config.PersistedQueries != nil {
+		srv.Use(PersistedQueryAllowlist{Queries: config.PersistedQueries})
+	} else {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](config.APQCacheSize)})
+	}
+
+	srv.Use(DepthLimit{MaxDepth: config.MaxDepth})
+	srv.Use(extension.FixedComplexityLimit(config.ComplexityLimit))
+
+	return srv
+}
@@ -0,0 +1,122 @@
+package graph_test
+
+import (
+	"github.com/99designs/gqlgen/client"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/graph"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+)
+
+func newLimitedClient(config graph.ServerConfig) *client.Client {
+	return client.New(graph.NewServer(&graph.Resolver{}, config))
+}
+
+func TestServer_DepthLimit(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{MaxDepth: 3})
+
+	var resp any
+	err := c.Post(`{ wallet(address: "A") { transfers { from { address } } } }`, &resp)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "operation has depth 4, which exceeds the limit of 3")
+}
+
+func TestServer_DepthLimitCountsFragments(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{MaxDepth: 3})
+
+	var resp any
+	err := c.Post(`
+		query { wallet(address: "A") { ...walletTransfers } }
+		fragment walletTransfers on Wallet { transfers { to { address } } }
+	`, &resp)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the limit of 3")
+}
+
+func TestServer_ComplexityLimitUsesCostAnnotations(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{ComplexityLimit: 1000})
+
+	// wallet (2) + transfers (5 + 100 * (from (1) + address (1))), it passes the limit and fails
+	// only later without a database
+	var resp any
+	err := c.Post(`{ wallet(address: "A") { transfers(limit: 100) { from { address } } } }`, &resp)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "complexity")
+
+	// wallet (2) + transfers (5 + 500 * 2)
+	err = c.Post(`{ wallet(address: "A") { transfers(limit: 500) { from { address } } } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "operation has complexity 1007, which exceeds the limit of 1000")
+}
+
+func TestServer_AutomaticPersistedQueryNotFound(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{})
+
+	var resp any
+	err := c.Post("", &resp, client.Extensions(map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": graph.QueryHash("{ __typename }")},
+	}))
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "PersistedQueryNotFound")
+
+	// Sending the query along with its hash registers it for later requests
+	err = c.Post("{ __typename }", &resp, client.Extensions(map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": graph.QueryHash("{ __typename }")},
+	}))
+	require.NoError(t, err)
+
+	err = c.Post("", &resp, client.Extensions(map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": graph.QueryHash("{ __typename }")},
+	}))
+	require.NoError(t, err)
+}
+
+func TestServer_PersistedQueryAllowlist(t *testing.T) {
+	allowed := "{ __typename }"
+	c := newLimitedClient(graph.ServerConfig{
+		PersistedQueries: map[string]string{graph.QueryHash(allowed): allowed},
+	})
+
+	var resp struct {
+		Typename string `json:"__typename"`
+	}
+	err := c.Post("", &resp, client.Extensions(map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": graph.QueryHash(allowed)},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "Query", resp.Typename)
+
+	err = c.Post(`{ wallet(address: "A") { balance } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only allowlisted persisted queries are allowed")
+}
+
+func TestServer_IntrospectionDisabled(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{Introspection: false})
+
+	var resp any
+	err := c.Post(`{ __schema { queryType { name } } }`, &resp)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "introspection disabled")
+}
+
+func TestServer_HasRole(t *testing.T) {
+	c := newLimitedClient(graph.ServerConfig{})
+
+	var resp any
+	err := c.Post(`mutation { mint(to: "A", amount: 1) { balance } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "forbidden: ADMIN role required")
+
+	user := func(r *client.Request) {
+		r.HTTP = r.HTTP.WithContext(auth.WithPrincipal(r.HTTP.Context(), &auth.Principal{Subject: "alice", Role: models.RoleUser}))
+	}
+	err = c.Post(`mutation { rebalanceShards(address: "A") { shards } }`, &resp, user)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "forbidden: ADMIN role required")
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"token-transfer-api/internal/models"
+)
+
+// JWTVerifier verifies HS256 tokens signed with a shared secret and RS256 tokens signed with
+// one of the keys of a local JWKS file
+type JWTVerifier struct {
+	HMACSecret []byte
+	// RSAKeys are the public keys by their key ID, the "kid" header of the token
+	RSAKeys map[string]*rsa.PublicKey
+	// Issuer and Audience are checked only when set
+	Issuer   string
+	Audience string
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Role models.Role `json:"role"`
+}
+
+// Verify checks the signature and claims of the token and returns the principal it was issued to
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if v.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		options = append(options, jwt.WithAudience(v.Audience))
+	}
+
+	var c claims
+	if _, err := jwt.ParseWithClaims(token, &c, v.key, options...); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if c.Subject == "" {
+		return nil, errors.New("invalid token: missing subject")
+	}
+	switch c.Role {
+	case "":
+		c.Role = models.RoleUser
+	case models.RoleAdmin, models.RoleUser:
+	default:
+		return nil, fmt.Errorf("invalid token: unknown role %q", c.Role)
+	}
+
+	return &Principal{Subject: c.Subject, Role: c.Role}, nil
+}
+
+// key picks the verification key matching the signing method of the token
+func (v *JWTVerifier) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case "HS256":
+		if len(v.HMACSecret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return v.HMACSecret, nil
+	case "RS256":
+		kid, _ := token.Header["kid"].(string)
+		key, ok := v.RSAKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// LoadJWKS reads the RSA public keys of a JSON Web Key Set file
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %q: %w", key.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	verifier := &auth.JWTVerifier{HMACSecret: []byte("secret")}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	principal, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{"sub": "alice", "exp": exp}))
+	require.NoError(t, err)
+	require.Equal(t, &auth.Principal{Subject: "alice", Role: models.RoleUser}, principal)
+
+	principal, err = verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{"sub": "bob", "role": "ADMIN", "exp": exp}))
+	require.NoError(t, err)
+	require.Equal(t, models.RoleAdmin, principal.Role)
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("other"), "", jwt.MapClaims{"sub": "alice", "exp": exp}))
+	require.Error(t, err)
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()}))
+	require.Error(t, err)
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{"sub": "alice", "role": "ROOT", "exp": exp}))
+	require.ErrorContains(t, err, "unknown role")
+}
+
+func TestJWTVerifier_RS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	keys, err := auth.LoadJWKS(path)
+	require.NoError(t, err)
+	verifier := &auth.JWTVerifier{RSAKeys: keys, Issuer: "issuer"}
+
+	claims := jwt.MapClaims{"sub": "alice", "iss": "issuer", "exp": time.Now().Add(time.Hour).Unix()}
+	principal, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, key, "test", claims))
+	require.NoError(t, err)
+	require.Equal(t, "alice", principal.Subject)
+
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodRS256, key, "unknown", claims))
+	require.ErrorContains(t, err, "unknown key ID")
+
+	// HS256 tokens are rejected without a shared secret, even if signed with the public key
+	_, err = verifier.Verify(sign(t, jwt.SigningMethodHS256, key.N.Bytes(), "test", claims))
+	require.Error(t, err)
+}
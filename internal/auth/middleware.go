@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Authenticator authenticates requests with an API key in the X-API-Key header or a JWT
+// in the Authorization: Bearer header
+type Authenticator struct {
+	DB  *gorm.DB
+	JWT *JWTVerifier
+}
+
+// Middleware rejects unauthenticated requests and puts the principal into the request context
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.Authenticate(r.Context(), r.Header.Get("X-API-Key"), r.Header.Get("Authorization"))
+		if err != nil {
+			log.Printf("Authentication failed: %v", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="token-transfer-api"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Authenticate returns the principal identified by the API key or the "Bearer <jwt>" authorization
+func (a *Authenticator) Authenticate(ctx context.Context, apiKey string, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		return authenticateAPIKey(a.DB.WithContext(ctx), apiKey)
+	}
+
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && a.JWT != nil {
+		return a.JWT.Verify(token)
+	}
+
+	return nil, errMissingCredentials
+}
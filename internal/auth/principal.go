@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"token-transfer-api/internal/models"
+)
+
+var (
+	// ErrForbidden is returned when the principal is not allowed to perform the operation
+	ErrForbidden = errors.New("forbidden")
+
+	errMissingCredentials = errors.New("missing API key or bearer token")
+)
+
+// Principal is the authenticated client making the request
+type Principal struct {
+	Subject string
+	Role    models.Role
+}
+
+type ctxKey struct{}
+
+// WithPrincipal returns a copy of the context carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, principal)
+}
+
+// FromContext returns the principal of the request, nil if the request is not authenticated
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(ctxKey{}).(*Principal)
+	return principal
+}
+
+// HasRole reports whether the principal has the role, admins have every role
+func (p *Principal) HasRole(role models.Role) bool {
+	return p != nil && (p.Role == models.RoleAdmin || p.Role == role)
+}
+
+// AuthorizeSpend checks that the principal of the request may move tokens out of the wallet.
+// Admins may move tokens out of any wallet, users only out of the wallets they own. Wallets created
+// by receiving tokens have no owner, so only admins can move their tokens until SetWalletOwner is called.
+func AuthorizeSpend(ctx context.Context, db *gorm.DB, address string) error {
+	principal := FromContext(ctx)
+	if principal == nil {
+		return fmt.Errorf("%w: not authenticated", ErrForbidden)
+	}
+	if principal.Role == models.RoleAdmin {
+		return nil
+	}
+
+	var wallet models.Wallet
+	err := db.WithContext(ctx).Select("address", "owner").First(&wallet, "address = ?", address).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load wallet: %w", err)
+	}
+	if err != nil || wallet.Owner != principal.Subject {
+		return fmt.Errorf("%w: wallet %s is not owned by %s", ErrForbidden, address, principal.Subject)
+	}
+	return nil
+}
+
+// SetWalletOwner gives the wallet to the subject, an empty subject leaves the wallet without an owner
+func SetWalletOwner(db *gorm.DB, address string, owner string) error {
+	res := db.Model(&models.Wallet{}).Where("address = ?", address).Update("owner", owner)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update wallet owner: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("wallet %s not found", address)
+	}
+	return nil
+}
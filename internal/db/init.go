@@ -4,14 +4,27 @@ import (
 	"fmt"
 	"time"
 	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
 	"os"
 )
 
+// Init connects to the database, migrates the schema and initializes the default wallet
 func Init() *gorm.DB {
+	// Wait 3 seconds to ensure the database container is up before connecting
+	time.Sleep(3 * time.Second)
+
+	DB := Connect()
+	Migrate(DB)
+	return DB
+}
+
+// Connect opens the connection pool to the database configured by the POSTGRES_* variables
+func Connect() *gorm.DB {
 	// Initialize PostgreSQL connection using env variables
 	user := os.Getenv("POSTGRES_USER")
 	pass := os.Getenv("POSTGRES_PASSWORD")
@@ -23,12 +36,7 @@ func Init() *gorm.DB {
 	}
 	databaseURL := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", user, pass, host, db)
 
-	fmt.Printf("Connecting to DB on %s using user %s", host, user)
-
-	// Wait 3 seconds to ensure the database container is up before connecting
-	time.Sleep(3 * time.Second)
-
-	var err error
+	log.Printf("Connecting to DB on %s using user %s", host, user)
 
 	DB, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
 	if err != nil {
@@ -47,27 +55,197 @@ func Init() *gorm.DB {
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	// Automatically migrate the schema for the Wallet model to the database
-	err = DB.AutoMigrate(&models.Wallet{})
+	return DB
+}
+
+// Models are all models stored in the database, every model comes after the models it refers to
+var Models = []any{&models.Wallet{}, &models.WalletShard{}, &models.Transfer{}, &models.APIKey{}, &models.ScheduledTransfer{},
+	&models.StandingOrder{}, &models.Hold{}, &models.Allowance{}, &models.FeeSchedule{}, &models.SpendingLimit{},
+	&models.AuditRecord{}, &models.PauseState{}, &models.ReconcileRun{},
+	&models.BalanceCheckpoint{}, &models.PaymentBatch{}, &models.PaymentTransaction{}, &models.OutboxEvent{},
+	&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.Event{}, &models.Account{},
+	&models.JournalEntry{}, &models.JournalPosting{},
+}
+
+// Migrate updates the schema for the models and, outside of the test environment, initializes the default wallet
+func Migrate(DB *gorm.DB) {
+	// Wallets existing before the ledger, the event store and the journal are imported into each of
+	// them once it is created, as opening mints, import events and the opening entry of the journal
+	openLedger := !DB.Migrator().HasTable(&models.Transfer{})
+	importWallets := !DB.Migrator().HasTable(&models.Event{})
+	openBalances := !DB.Migrator().HasTable(&models.JournalEntry{})
+
+	// Automatically migrate the schema for the models to the database
+	err := DB.AutoMigrate(Models...)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if openLedger {
+		if err := mintOpeningBalances(DB); err != nil {
+			log.Fatalf("Failed to record the opening balances in the ledger: %v", err)
+		}
+	}
+	if importWallets {
+		if err := importEvents(DB); err != nil {
+			log.Fatalf("Failed to import wallets into the event store: %v", err)
+		}
+	}
+
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SystemAccounts).Error; err != nil {
+		log.Fatalf("Failed to create system accounts: %v", err)
+	}
+	if openBalances {
+		if err := openJournal(DB); err != nil {
+			log.Fatalf("Failed to open the journal: %v", err)
+		}
+	}
+
 	// If not running in the test environment, initialize the database with a default wallet if it doesn't exist
 	if os.Getenv("INIT_ENV") != "test" {
 		initDefaultWallet("0x0000000000000000000000000000000000000000", 1000000, DB)
 	}
-	return DB
 }
 
 func initDefaultWallet(Address string, Balance int, DB *gorm.DB) {
 	var count int64
-	DB.Model(&models.Wallet{}).Count(&count)
-	log.Printf("Number of wallets in DB: %d\n", count)
+	// The balance is minted through the service, so it is recorded in the ledger, the event store,
+	// the journal and the outbox like any other mint
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Wallet{}).Count(&count).Error; err != nil {
+			return err
+		}
+		log.Printf("Number of wallets in DB: %d\n", count)
+		if count > 0 {
+			return nil
+		}
+		_, err := service.Mint(tx, Address, Balance)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize default wallet: %v", err)
+	}
 	if count == 0 {
-		DB.Create(&models.Wallet{Address: Address, Balance: Balance})
 		log.Printf("Wallet %s initialized with balance %d", Address, Balance)
 	} else {
 		log.Printf("Default wallet already initialized")
 	}
 }
+
+// mintOpeningBalances records the balance of every wallet, including its held balance and slots, as
+// a completed MINT entry, so that the ledger adds up to the balances it didn't record.
+// Balance changes wait until it is done.
+func mintOpeningBalances(DB *gorm.DB) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE wallets, wallet_shards IN SHARE MODE").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO transfers (kind, from_address, to_address, amount, status, created_at, updated_at)
+			SELECT ?, '', w.address, w.balance + w.held + COALESCE(SUM(s.balance), 0), ?, NOW(), NOW()
+			FROM wallets w LEFT JOIN wallet_shards s ON s.address = w.address
+			GROUP BY w.address, w.balance, w.held
+			HAVING w.balance + w.held + COALESCE(SUM(s.balance), 0) > 0
+			ORDER BY w.address`,
+			models.KindMint, models.TransferCompleted,
+		).Error
+	})
+}
+
+// importEvents records the current state of every wallet as a WalletImported event, so that the
+// balances rebuilt from the event store start from them. Balance changes wait until it is done.
+func importEvents(DB *gorm.DB) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE wallets, wallet_shards IN SHARE MODE").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO events (type, data, created_at)
+			SELECT ?, jsonb_strip_nulls(jsonb_build_object(
+				'address', w.address,
+				'balance', NULLIF(w.balance + COALESCE(SUM(s.balance), 0), 0),
+				'held', NULLIF(w.held, 0),
+				'sendFrozen', NULLIF(w.send_frozen, false),
+				'receiveFrozen', NULLIF(w.receive_frozen, false),
+				'reason', NULLIF(w.freeze_reason, '')
+			)), NOW()
+			FROM wallets w LEFT JOIN wallet_shards s ON s.address = w.address
+			GROUP BY w.address
+			ORDER BY w.address`,
+			models.EventWalletImported,
+		).Error
+	})
+}
+
+// openJournal posts the balances of the existing wallets against the issuance account as the opening
+// entry of the journal, with the held balances in suspense and the fees wallets collected as revenue.
+// Balance changes wait until it is done.
+func openJournal(DB *gorm.DB) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE wallets, wallet_shards IN SHARE MODE").Error; err != nil {
+			return err
+		}
+
+		var wallets []struct {
+			Address string
+			Balance int
+			Held    int
+			Fees    int
+		}
+		err := tx.Raw(`
+			SELECT w.address, w.balance + COALESCE(SUM(s.balance), 0) AS balance, w.held, COALESCE(f.fees, 0) AS fees
+			FROM wallets w LEFT JOIN wallet_shards s ON s.address = w.address
+			LEFT JOIN (
+				SELECT to_address, SUM(amount) AS fees FROM transfers WHERE kind = ? AND status = ? GROUP BY to_address
+			) f ON f.to_address = w.address
+			GROUP BY w.address, f.fees
+			ORDER BY w.address`,
+			models.KindFee, models.TransferCompleted,
+		).Scan(&wallets).Error
+		if err != nil || len(wallets) == 0 {
+			return err
+		}
+
+		var accounts []models.Account
+		var postings []models.JournalPosting
+		issued, held, fees := 0, 0, 0
+		for _, wallet := range wallets {
+			issued += wallet.Balance + wallet.Held
+			held += wallet.Held
+			fees += wallet.Fees
+			account := models.WalletAccount(wallet.Address)
+			accounts = append(accounts, account)
+			if owed := wallet.Balance - wallet.Fees; owed != 0 {
+				postings = append(postings, models.JournalPosting{AccountCode: account.Code, Amount: -owed})
+			}
+		}
+		if fees != 0 {
+			postings = append(postings, models.JournalPosting{AccountCode: models.AccountFees, Amount: -fees})
+		}
+		if held != 0 {
+			postings = append(postings, models.JournalPosting{AccountCode: models.AccountSuspense, Amount: -held})
+		}
+		if issued != 0 {
+			postings = append(postings, models.JournalPosting{AccountCode: models.AccountIssuance, Amount: issued})
+		}
+
+		if len(accounts) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&accounts, 1000).Error; err != nil {
+				return err
+			}
+		}
+		if len(postings) == 0 {
+			return nil
+		}
+
+		// The postings are inserted in batches, there is one per wallet
+		entry := &models.JournalEntry{Description: "Opening balances"}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		for i := range postings {
+			postings[i].EntryID = entry.ID
+		}
+		return tx.CreateInBatches(&postings, 1000).Error
+	})
+}
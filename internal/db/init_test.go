@@ -0,0 +1,39 @@
+package db_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestMigrate_OpensLedgerOfExistingWallets(t *testing.T) {
+	testDB := db.Connect()
+
+	// Start from a database holding only the wallets, as before the ledger existed
+	require.NoError(t, testDB.Migrator().DropTable(db.Models...))
+	require.NoError(t, testDB.AutoMigrate(&models.Wallet{}, &models.WalletShard{}))
+	require.NoError(t, testDB.Create(&[]models.Wallet{
+		{Address: "0x0000000000000000000000000000000000000000", Balance: 1000000},
+		{Address: "A", Balance: 30, Shards: 2},
+		{Address: "B"},
+	}).Error)
+	require.NoError(t, testDB.Create(&[]models.WalletShard{
+		{Address: "A", Slot: 0, Balance: 5},
+		{Address: "A", Slot: 1, Balance: 7},
+	}).Error)
+
+	db.Migrate(testDB)
+
+	report, err := service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%+v", report)
+	require.Equal(t, 1000042, report.TotalSupply)
+
+	// The opening balances are only recorded once
+	db.Migrate(testDB)
+	var mints int64
+	require.NoError(t, testDB.Model(&models.Transfer{}).Where("kind = ?", models.KindMint).Count(&mints).Error)
+	require.EqualValues(t, 2, mints)
+}
@@ -0,0 +1,38 @@
+// Package dbtest prepares the test database shared by the tests of all packages
+package dbtest
+
+import (
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"sync"
+	"testing"
+	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/models"
+)
+
+var (
+	connect sync.Once
+	testDB  *gorm.DB
+)
+
+// Setup deletes the rows of every model in the test database, so each test starts from an empty ledger.
+// The first test of a package connects and migrates the schema, the others reuse the connection pool.
+// The packages share the database, so their tests must not run in parallel (go test -p 1).
+func Setup(t *testing.T) *gorm.DB {
+	connect.Do(func() {
+		testDB = db.Connect()
+		db.Migrate(testDB)
+	})
+
+	// Delete in reverse order of migration, so rows go before the rows they refer to
+	for i := len(db.Models) - 1; i >= 0; i-- {
+		query := testDB.Session(&gorm.Session{AllowGlobalUpdate: true})
+		// The system accounts are created by the migration
+		if _, ok := db.Models[i].(*models.Account); ok {
+			query = query.Where("address <> ''")
+		}
+		require.NoError(t, query.Delete(db.Models[i]).Error)
+	}
+
+	return testDB
+}
@@ -0,0 +1,36 @@
+package grpcapi
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"token-transfer-api/internal/service"
+)
+
+// statuses maps the codes of the service layer to gRPC codes, the way the REST API maps them to HTTP statuses
+var statuses = map[service.Code]codes.Code{
+	service.CodeInvalidArgument:     codes.InvalidArgument,
+	service.CodeForbidden:           codes.PermissionDenied,
+	service.CodeWalletNotFound:      codes.NotFound,
+	service.CodeWalletFrozen:        codes.FailedPrecondition,
+	service.CodeAddressBlocked:      codes.PermissionDenied,
+	service.CodePaused:              codes.Unavailable,
+	service.CodeConflict:            codes.Aborted,
+	service.CodeInsufficientBalance: codes.FailedPrecondition,
+	service.CodeLimitExceeded:       codes.ResourceExhausted,
+}
+
+// toStatus maps the error of the service layer to a gRPC status.
+// Unexpected errors are only logged, the client gets a generic message.
+func toStatus(err error) error {
+	code, ok := statuses[service.Classify(err)]
+	if !ok {
+		log.Printf("Request failed: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+	return status.Error(code, err.Error())
+}
+
+func invalidArgument(message string) error {
+	return status.Error(codes.InvalidArgument, message)
+}
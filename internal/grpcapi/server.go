@@ -0,0 +1,163 @@
+package grpcapi
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"strconv"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+	transferv1 "token-transfer-api/proto/transfer/v1"
+)
+
+const (
+	// defaultPageSize is the number of transfers listed when the request has no limit
+	defaultPageSize = 50
+	// watchBatchSize is the maximum number of entries WatchTransfers loads per poll
+	watchBatchSize = 100
+)
+
+type server struct {
+	transferv1.UnimplementedTransferServiceServer
+	db *gorm.DB
+}
+
+// NewServer sets up a gRPC server with the TransferService, backed by the same service functions
+// as the GraphQL and REST APIs
+func NewServer(db *gorm.DB, authenticator *auth.Authenticator) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(unaryAuth(authenticator)),
+		grpc.StreamInterceptor(streamAuth(authenticator)),
+	)
+	transferv1.RegisterTransferServiceServer(srv, &server{db: db})
+	return srv
+}
+
+func (s *server) Transfer(ctx context.Context, req *transferv1.TransferRequest) (*transferv1.TransferResponse, error) {
+	if err := auth.AuthorizeSpend(ctx, s.db, req.From); err != nil {
+		return nil, toStatus(err)
+	}
+
+	entry, balance, err := service.TransferRecorded(s.db.WithContext(ctx), req.From, req.To, int(req.Amount))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &transferv1.TransferResponse{Balance: int64(balance), Fee: int64(entry.Fee)}, nil
+}
+
+func (s *server) GetWallet(ctx context.Context, req *transferv1.GetWalletRequest) (*transferv1.GetWalletResponse, error) {
+	wallet, err := service.Wallet(s.db.WithContext(ctx), req.Address)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &transferv1.GetWalletResponse{Wallet: &transferv1.Wallet{
+		Address:       wallet.Address,
+		Owner:         wallet.Owner,
+		Balance:       int64(wallet.Balance),
+		Held:          int64(wallet.Held),
+		Shards:        int32(wallet.Shards),
+		SendFrozen:    wallet.SendFrozen,
+		ReceiveFrozen: wallet.ReceiveFrozen,
+	}}, nil
+}
+
+func (s *server) ListTransfers(ctx context.Context, req *transferv1.ListTransfersRequest) (*transferv1.ListTransfersResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+
+	// The cursor is the ID of the last transfer of the previous page, clients treat it as opaque
+	var before uint64
+	if req.Cursor != "" {
+		var err error
+		if before, err = strconv.ParseUint(req.Cursor, 10, 64); err != nil || before == 0 {
+			return nil, invalidArgument("invalid cursor")
+		}
+	}
+
+	transfers, err := service.ListTransfers(s.db.WithContext(ctx), req.Address, uint(before), limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &transferv1.ListTransfersResponse{Transfers: make([]*transferv1.Transfer, len(transfers))}
+	for i := range transfers {
+		resp.Transfers[i] = toTransfer(&transfers[i])
+	}
+	if len(transfers) == limit {
+		resp.NextCursor = strconv.FormatUint(uint64(transfers[limit-1].ID), 10)
+	}
+	return resp, nil
+}
+
+// WatchTransfers follows the changes feed and streams the entries after the last sent one
+func (s *server) WatchTransfers(req *transferv1.WatchTransfersRequest, stream grpc.ServerStreamingServer[transferv1.WatchTransfersResponse]) error {
+	ctx := stream.Context()
+	since := req.Since
+
+	for {
+		transfers, err := service.WaitForChanges(ctx, s.db.WithContext(ctx), req.Address, since, watchBatchSize)
+		// The client is gone
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+
+		for i := range transfers {
+			if err := stream.Send(&transferv1.WatchTransfersResponse{Transfer: toTransfer(&transfers[i])}); err != nil {
+				return err
+			}
+			since = *transfers[i].Sequence
+		}
+	}
+}
+
+var transferKinds = map[models.TransferKind]transferv1.TransferKind{
+	models.KindTransfer: transferv1.TransferKind_TRANSFER_KIND_TRANSFER,
+	models.KindMint:     transferv1.TransferKind_TRANSFER_KIND_MINT,
+	models.KindBurn:     transferv1.TransferKind_TRANSFER_KIND_BURN,
+	models.KindFee:      transferv1.TransferKind_TRANSFER_KIND_FEE,
+	models.KindReversal: transferv1.TransferKind_TRANSFER_KIND_REVERSAL,
+	models.KindRefund:   transferv1.TransferKind_TRANSFER_KIND_REFUND,
+}
+
+var transferStatuses = map[models.TransferStatus]transferv1.TransferStatus{
+	models.TransferPending:   transferv1.TransferStatus_TRANSFER_STATUS_PENDING,
+	models.TransferCompleted: transferv1.TransferStatus_TRANSFER_STATUS_COMPLETED,
+	models.TransferFailed:    transferv1.TransferStatus_TRANSFER_STATUS_FAILED,
+
+	models.TransferReversed:          transferv1.TransferStatus_TRANSFER_STATUS_REVERSED,
+	models.TransferPartiallyRefunded: transferv1.TransferStatus_TRANSFER_STATUS_PARTIALLY_REFUNDED,
+	models.TransferRefunded:          transferv1.TransferStatus_TRANSFER_STATUS_REFUNDED,
+}
+
+// toTransfer converts the database transfer into its protobuf representation
+func toTransfer(transfer *models.Transfer) *transferv1.Transfer {
+	result := &transferv1.Transfer{
+		Id:        uint64(transfer.ID),
+		Kind:      transferKinds[transfer.Kind],
+		From:      transfer.FromAddress,
+		To:        transfer.ToAddress,
+		Amount:    int64(transfer.Amount),
+		Fee:       int64(transfer.Fee),
+		Status:    transferStatuses[transfer.Status],
+		Reason:    transfer.Reason,
+		Refunded:  int64(transfer.Refunded),
+		CreatedAt: timestamppb.New(transfer.CreatedAt),
+		UpdatedAt: timestamppb.New(transfer.UpdatedAt),
+	}
+	if transfer.ParentID != nil {
+		result.ParentId = uint64(*transfer.ParentID)
+	}
+	if transfer.Sequence != nil {
+		result.Sequence = *transfer.Sequence
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package grpcapi_test
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+	"net"
+	"testing"
+	"time"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/grpcapi"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+	transferv1 "token-transfer-api/proto/transfer/v1"
+)
+
+var secret = []byte("secret")
+
+// newClient serves the TransferService over an in-memory connection
+func newClient(t *testing.T, testDB *gorm.DB) transferv1.TransferServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(testDB, &auth.Authenticator{DB: testDB, JWT: &auth.JWTVerifier{HMACSecret: secret}})
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return transferv1.NewTransferServiceClient(conn)
+}
+
+// as returns a context authenticated with a JWT of the subject
+func as(t *testing.T, subject string, role models.Role) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  subject,
+		"role": role,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestTransferService_Unauthenticated(t *testing.T) {
+	client := newClient(t, nil)
+
+	_, err := client.Transfer(context.Background(), &transferv1.TransferRequest{From: "A", To: "B", Amount: 1})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTransferService_Transfer(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	client := newClient(t, testDB)
+	ctx := as(t, "alice", models.RoleUser)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10, Owner: "alice"}).Error)
+
+	resp, err := client.Transfer(ctx, &transferv1.TransferRequest{From: "A", To: "B", Amount: 4})
+	require.NoError(t, err)
+	require.EqualValues(t, 6, resp.Balance)
+
+	wallet, err := client.GetWallet(ctx, &transferv1.GetWalletRequest{Address: "B"})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, wallet.Wallet.Balance)
+
+	_, err = client.Transfer(ctx, &transferv1.TransferRequest{From: "A", To: "B", Amount: 0})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Transfer(ctx, &transferv1.TransferRequest{From: "A", To: "B", Amount: 100})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.Transfer(ctx, &transferv1.TransferRequest{From: "B", To: "A", Amount: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetWallet(ctx, &transferv1.GetWalletRequest{Address: "C"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTransferService_ListAndWatchTransfers(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	sequencing, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	service.NewSequencer(testDB, service.SequencerConfig{Interval: 10 * time.Millisecond}).Start(sequencing)
+
+	interval := service.ChangesPollInterval
+	service.ChangesPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { service.ChangesPollInterval = interval })
+	client := newClient(t, testDB)
+	ctx := as(t, "admin", models.RoleAdmin)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	for _, to := range []string{"B", "C", "D"} {
+		_, err := service.Transfer(testDB, "A", to, 1)
+		require.NoError(t, err)
+	}
+
+	page, err := client.ListTransfers(ctx, &transferv1.ListTransfersRequest{Address: "A", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 2)
+	require.Equal(t, "D", page.Transfers[0].To)
+
+	page, err = client.ListTransfers(ctx, &transferv1.ListTransfersRequest{Address: "A", Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	require.Empty(t, page.NextCursor)
+
+	// The stream sends the existing entries first, then the entries settled later
+	watchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchTransfers(watchCtx, &transferv1.WatchTransfersRequest{Address: "A"})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "B", resp.Transfer.To)
+	require.Positive(t, resp.Transfer.Sequence)
+
+	for _, to := range []string{"C", "D"} {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, to, resp.Transfer.To)
+	}
+
+	_, err = service.Transfer(testDB, "A", "E", 1)
+	require.NoError(t, err)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "E", resp.Transfer.To)
+	require.Equal(t, transferv1.TransferStatus_TRANSFER_STATUS_COMPLETED, resp.Transfer.Status)
+}
@@ -0,0 +1,198 @@
+// Package iso20022 maps the ledger to ISO 20022 messages: camt.053 account statements,
+// pain.001 credit transfer initiations and the pain.002 status reports answering them
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"time"
+	"token-transfer-api/internal/service"
+)
+
+// Currency is the ISO 4217 code the token amounts are reported in, XXX stands for no currency
+var Currency = "XXX"
+
+// Issuer identifies this ledger as the issuer of proprietary codes and identifiers
+const Issuer = "token-transfer-api"
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    int    `xml:",chardata"`
+}
+
+type dateTime struct {
+	DateTime string `xml:"DtTm"`
+}
+
+// account identifies a wallet by its address
+type account struct {
+	ID struct {
+		Other struct {
+			ID string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+}
+
+func walletAccount(address string) *account {
+	a := &account{}
+	a.ID.Other.ID = address
+	return a
+}
+
+type balance struct {
+	Type struct {
+		Code string `xml:"CdOrPrtry>Cd"`
+	} `xml:"Tp"`
+	Amount    amount   `xml:"Amt"`
+	Indicator string   `xml:"CdtDbtInd"`
+	Date      dateTime `xml:"Dt"`
+}
+
+func newBalance(code string, value int, at time.Time) balance {
+	b := balance{Amount: amount{Currency: Currency, Value: value}, Indicator: "CRDT", Date: dateTime{formatTime(at)}}
+	b.Type.Code = code
+	if value < 0 {
+		b.Amount.Value, b.Indicator = -value, "DBIT"
+	}
+	return b
+}
+
+type party struct {
+	Name string `xml:"Pty>Nm"`
+}
+
+type relatedParties struct {
+	Debtor          *party   `xml:"Dbtr,omitempty"`
+	DebtorAccount   *account `xml:"DbtrAcct,omitempty"`
+	Creditor        *party   `xml:"Cdtr,omitempty"`
+	CreditorAccount *account `xml:"CdtrAcct,omitempty"`
+}
+
+type entry struct {
+	XMLName     xml.Name `xml:"Ntry"`
+	Reference   string   `xml:"NtryRef"`
+	Amount      amount   `xml:"Amt"`
+	Indicator   string   `xml:"CdtDbtInd"`
+	Status      string   `xml:"Sts>Cd"`
+	BookingDate dateTime `xml:"BookgDt"`
+	ValueDate   dateTime `xml:"ValDt"`
+	// The kind of the ledger entry is a proprietary bank transaction code
+	TransactionCode struct {
+		Code   string `xml:"Cd"`
+		Issuer string `xml:"Issr"`
+	} `xml:"BkTxCd>Prtry"`
+	Details struct {
+		References struct {
+			EndToEndID string `xml:"EndToEndId"`
+		} `xml:"Refs"`
+		Amount         amount          `xml:"Amt"`
+		Indicator      string          `xml:"CdtDbtInd"`
+		RelatedParties *relatedParties `xml:"RltdPties,omitempty"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// WriteCamt053 streams the statement as a camt.053 bank-to-customer statement. The balances come first,
+// so the closing balance is computed before the entries are loaded.
+func WriteCamt053(db *gorm.DB, out io.Writer, statement *service.Statement) error {
+	closing, err := statement.Closing(db)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	id := fmt.Sprintf("STMT-%s-%d", statement.From.UTC().Format("20060102"), now.UnixNano())
+	period := struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	}{formatTime(statement.From), formatTime(statement.To)}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	// The document is written element by element, so that the entries don't have to fit in memory
+	opened := []xml.StartElement{
+		{Name: xml.Name{Local: "Document"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}}},
+		{Name: xml.Name{Local: "BkToCstmrStmt"}},
+	}
+	steps := []func() error{
+		func() error { return encoder.EncodeToken(opened[0]) },
+		func() error { return encoder.EncodeToken(opened[1]) },
+		func() error {
+			return element(encoder, "GrpHdr", struct {
+				MessageID string `xml:"MsgId"`
+				Created   string `xml:"CreDtTm"`
+			}{id, formatTime(now)})
+		},
+		func() error { return encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Stmt"}}) },
+		func() error { return element(encoder, "Id", id) },
+		func() error { return element(encoder, "CreDtTm", formatTime(now)) },
+		func() error { return element(encoder, "FrToDt", period) },
+		func() error { return element(encoder, "Acct", walletAccount(statement.Address)) },
+		func() error { return element(encoder, "Bal", newBalance("OPBD", statement.Opening, statement.From)) },
+		func() error { return element(encoder, "Bal", newBalance("CLBD", closing, statement.To)) },
+		func() error {
+			_, err := statement.Entries(db, func(e service.StatementEntry) error {
+				return encoder.Encode(toEntry(e))
+			})
+			return err
+		},
+		func() error { return encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "Stmt"}}) },
+		func() error { return encoder.EncodeToken(opened[1].End()) },
+		func() error { return encoder.EncodeToken(opened[0].End()) },
+		encoder.Flush,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(out, "\n")
+	return err
+}
+
+func toEntry(e service.StatementEntry) entry {
+	result := entry{
+		Reference:   strconv.FormatUint(uint64(e.ID), 10),
+		Amount:      amount{Currency: Currency, Value: e.Credit + e.Debit},
+		Indicator:   "CRDT",
+		Status:      "BOOK",
+		BookingDate: dateTime{formatTime(e.Time)},
+		ValueDate:   dateTime{formatTime(e.Time)},
+	}
+	if e.Debit > 0 {
+		result.Indicator = "DBIT"
+	}
+	result.TransactionCode.Code = string(e.Kind)
+	result.TransactionCode.Issuer = Issuer
+
+	details := &result.Details
+	details.References.EndToEndID = result.Reference
+	details.Amount, details.Indicator = result.Amount, result.Indicator
+	if e.Counterparty != "" {
+		details.RelatedParties = &relatedParties{}
+		if e.Debit > 0 {
+			details.RelatedParties.Creditor = &party{Name: e.Counterparty}
+			details.RelatedParties.CreditorAccount = walletAccount(e.Counterparty)
+		} else {
+			details.RelatedParties.Debtor = &party{Name: e.Counterparty}
+			details.RelatedParties.DebtorAccount = walletAccount(e.Counterparty)
+		}
+	}
+	return result
+}
+
+func element(encoder *xml.Encoder, name string, value any) error {
+	return encoder.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
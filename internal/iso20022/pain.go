@@ -0,0 +1,324 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+const (
+	pain001Prefix    = "urn:iso:std:iso:20022:tech:xsd:pain.001."
+	pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.10"
+)
+
+// MaxTransactions is the maximum number of credit transfers of a pain.001 document, which is executed
+// while its submitter waits
+var MaxTransactions = 1000
+
+// Pain001 is a customer credit transfer initiation, only the elements needed to execute it are decoded
+type Pain001 struct {
+	XMLName xml.Name
+	Header  struct {
+		MessageID            string `xml:"MsgId"`
+		NumberOfTransactions string `xml:"NbOfTxs"`
+		ControlSum           string `xml:"CtrlSum"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Payments []struct {
+		ID            string  `xml:"PmtInfId"`
+		Method        string  `xml:"PmtMtd"`
+		DebtorAccount account `xml:"DbtrAcct"`
+		Transactions  []struct {
+			EndToEndID string `xml:"PmtId>EndToEndId"`
+			Amount     struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			CreditorAccount account `xml:"CdtrAcct"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+// Pain002 is the customer payment status report answering a pain.001 initiation
+type Pain002 struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.002.001.10 Document"`
+	Header  struct {
+		MessageID string `xml:"MsgId"`
+		Created   string `xml:"CreDtTm"`
+	} `xml:"CstmrPmtStsRpt>GrpHdr"`
+	Original struct {
+		MessageID            string        `xml:"OrgnlMsgId"`
+		MessageName          string        `xml:"OrgnlMsgNmId"`
+		NumberOfTransactions string        `xml:"OrgnlNbOfTxs,omitempty"`
+		Status               string        `xml:"GrpSts"`
+		Reason               *statusReason `xml:"StsRsnInf,omitempty"`
+	} `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts"`
+	Payments []paymentStatus `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts"`
+}
+
+type paymentStatus struct {
+	ID           string              `xml:"OrgnlPmtInfId"`
+	Transactions []transactionStatus `xml:"TxInfAndSts"`
+}
+
+type transactionStatus struct {
+	EndToEndID string        `xml:"OrgnlEndToEndId"`
+	Status     string        `xml:"TxSts"`
+	Reason     *statusReason `xml:"StsRsnInf,omitempty"`
+}
+
+type statusReason struct {
+	Code        string `xml:"Rsn>Cd"`
+	Information string `xml:"AddtlInf,omitempty"`
+}
+
+// Group and transaction statuses of the pain.002 report
+const (
+	statusAccepted = "ACSC"
+	statusPartial  = "PART"
+	statusRejected = "RJCT"
+)
+
+// ParsePain001 decodes a pain.001 document of any version
+func ParsePain001(r io.Reader) (*Pain001, error) {
+	var document Pain001
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: invalid pain.001 document: %w", service.ErrInvalidArgument, err)
+	}
+	if document.XMLName.Local != "Document" || !strings.HasPrefix(document.XMLName.Space, pain001Prefix) {
+		return nil, fmt.Errorf("%w: not a pain.001 document", service.ErrInvalidArgument)
+	}
+	if document.Header.MessageID == "" {
+		return nil, fmt.Errorf("%w: pain.001 document has no message ID", service.ErrInvalidArgument)
+	}
+	count := 0
+	for _, payment := range document.Payments {
+		count += len(payment.Transactions)
+	}
+	if count > MaxTransactions {
+		return nil, fmt.Errorf("%w: pain.001 document has more than %d transactions", service.ErrInvalidArgument, MaxTransactions)
+	}
+	return &document, nil
+}
+
+// ExecutePain001 executes every credit transfer of the document as a separate transfer, on behalf of
+// the initiator, and returns the pain.002 status report. authorize is called with each debtor account
+// before its transfers are executed. A document the initiator already submitted isn't executed again,
+// the report of the first submission is returned instead. If the first submission was interrupted,
+// e.g. by a crash, submitting it again executes only the transactions without a recorded outcome.
+func ExecutePain001(db *gorm.DB, document *Pain001, initiator string, authorize func(debtor string) error) ([]byte, error) {
+	batch := &models.PaymentBatch{Initiator: initiator, MessageID: document.Header.MessageID}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(batch).Error; err != nil {
+		return nil, fmt.Errorf("failed to record payment batch: %w", err)
+	}
+
+	var report []byte
+	// The batch stays locked while it is executed, the lock is released if the execution is interrupted
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var batches []models.PaymentBatch
+		err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE", Options: "SKIP LOCKED"}).
+			Where("initiator = ? AND message_id = ?", initiator, batch.MessageID).
+			Find(&batches).Error
+		if err != nil {
+			return fmt.Errorf("failed to load payment batch: %w", err)
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("%w: payment batch %s is still being executed", service.ErrConflict, batch.MessageID)
+		}
+		if report = batches[0].Report; len(report) > 0 {
+			return nil
+		}
+
+		status, err := execute(db, &batches[0], document, authorize)
+		if err != nil {
+			return err
+		}
+		if report, err = xml.MarshalIndent(status, "", "  "); err != nil {
+			return err
+		}
+		report = append([]byte(xml.Header), report...)
+
+		if err := tx.Model(&batches[0]).Update("report", report).Error; err != nil {
+			return fmt.Errorf("failed to record payment batch: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func execute(db *gorm.DB, batch *models.PaymentBatch, document *Pain001, authorize func(debtor string) error) (*Pain002, error) {
+	report := &Pain002{}
+	report.Header.MessageID = "STS-" + document.Header.MessageID
+	report.Header.Created = formatTime(time.Now())
+	report.Original.MessageID = document.Header.MessageID
+	report.Original.MessageName = strings.TrimPrefix(document.XMLName.Space, "urn:iso:std:iso:20022:tech:xsd:")
+	report.Original.NumberOfTransactions = document.Header.NumberOfTransactions
+
+	// The group header must match the transactions, otherwise none of them is executed
+	count, sum, valid := 0, 0, true
+	for _, payment := range document.Payments {
+		for _, transaction := range payment.Transactions {
+			count++
+			value, err := parseAmount(transaction.Amount.Value)
+			sum += value
+			valid = valid && err == nil
+		}
+	}
+	switch {
+	case document.Header.NumberOfTransactions != strconv.Itoa(count):
+		report.Original.Status = statusRejected
+		report.Original.Reason = &statusReason{Code: "AM18", Information: fmt.Sprintf("the document has %d transactions", count)}
+		return report, nil
+	case document.Header.ControlSum != "" && valid && !sameAmount(document.Header.ControlSum, sum):
+		report.Original.Status = statusRejected
+		report.Original.Reason = &statusReason{Code: "AM10", Information: fmt.Sprintf("the amounts add up to %d", sum)}
+		return report, nil
+	}
+
+	// The transactions executed before an interruption keep their outcome
+	var executed []models.PaymentTransaction
+	err := db.Where("initiator = ? AND message_id = ?", batch.Initiator, batch.MessageID).Find(&executed).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment transactions: %w", err)
+	}
+	outcomes := make(map[int]models.PaymentTransaction, len(executed))
+	for _, outcome := range executed {
+		outcomes[outcome.Position] = outcome
+	}
+
+	accepted, position := 0, 0
+	for _, payment := range document.Payments {
+		status := paymentStatus{ID: payment.ID}
+		debtor := payment.DebtorAccount.ID.Other.ID
+
+		for _, transaction := range payment.Transactions {
+			outcome, ok := outcomes[position]
+			if !ok {
+				outcome = models.PaymentTransaction{
+					Initiator:  batch.Initiator,
+					MessageID:  batch.MessageID,
+					Position:   position,
+					EndToEndID: transaction.EndToEndID,
+					Status:     statusAccepted,
+				}
+
+				// The outcome of an accepted transaction is committed together with its transfer
+				err := db.Transaction(func(tx *gorm.DB) error {
+					if payment.Method != "TRF" {
+						return reject("NARR", "only credit transfers are supported")
+					}
+					if transaction.Amount.Currency != Currency {
+						return reject("AM11", "the currency must be "+Currency)
+					}
+					amount, err := parseAmount(transaction.Amount.Value)
+					if err != nil {
+						return err
+					}
+					if err := authorize(debtor); err != nil {
+						return err
+					}
+					entry, _, err := service.TransferRecorded(tx, debtor, transaction.CreditorAccount.ID.Other.ID, amount)
+					if err != nil {
+						return err
+					}
+					outcome.TransferID = &entry.ID
+					return tx.Create(&outcome).Error
+				})
+				if err != nil {
+					rejected := reason(err)
+					outcome.Status, outcome.ReasonCode, outcome.ReasonInfo, outcome.TransferID = statusRejected, rejected.Code, rejected.Information, nil
+					if err := db.Create(&outcome).Error; err != nil {
+						return nil, fmt.Errorf("failed to record payment transaction: %w", err)
+					}
+				}
+			}
+			position++
+
+			transactionStatus := transactionStatus{EndToEndID: outcome.EndToEndID, Status: outcome.Status}
+			if outcome.Status == statusRejected {
+				transactionStatus.Reason = &statusReason{Code: outcome.ReasonCode, Information: outcome.ReasonInfo}
+			} else {
+				accepted++
+			}
+			status.Transactions = append(status.Transactions, transactionStatus)
+		}
+		report.Payments = append(report.Payments, status)
+	}
+
+	switch accepted {
+	case count:
+		report.Original.Status = statusAccepted
+	case 0:
+		report.Original.Status = statusRejected
+	default:
+		report.Original.Status = statusPartial
+	}
+	return report, nil
+}
+
+// rejection is a validation failure with its ISO 20022 status reason code
+type rejection struct {
+	statusReason
+}
+
+func (r *rejection) Error() string {
+	return r.Information
+}
+
+func reject(code string, information string) error {
+	return &rejection{statusReason{Code: code, Information: information}}
+}
+
+// reason maps the error of a transfer to an ISO 20022 status reason, unexpected errors are only logged
+func reason(err error) *statusReason {
+	var rejected *rejection
+	if errors.As(err, &rejected) {
+		return &rejected.statusReason
+	}
+
+	code, ok := reasons[service.Classify(err)]
+	if !ok {
+		log.Printf("Failed to execute credit transfer: %v", err)
+		return &statusReason{Code: "NARR", Information: "internal error"}
+	}
+	return &statusReason{Code: code, Information: err.Error()}
+}
+
+// reasons maps the codes of the service layer to ISO 20022 status reason codes, NARR leaves the explanation to the message
+var reasons = map[service.Code]string{
+	service.CodeInvalidArgument:     "NARR",
+	service.CodePaused:              "NARR",
+	service.CodeForbidden:           "AG01",
+	service.CodeWalletNotFound:      "AC01",
+	service.CodeWalletFrozen:        "AC06",
+	service.CodeAddressBlocked:      "RR04",
+	service.CodeInsufficientBalance: "AM04",
+	service.CodeLimitExceeded:       "AM02",
+}
+
+// parseAmount accepts whole token amounts, with or without zero decimals
+func parseAmount(value string) (int, error) {
+	whole, decimals, _ := strings.Cut(strings.TrimSpace(value), ".")
+	amount, err := strconv.Atoi(whole)
+	if err != nil || amount <= 0 || strings.Trim(decimals, "0") != "" {
+		return 0, reject("AM12", fmt.Sprintf("invalid amount %q, it must be a positive whole number of tokens", value))
+	}
+	return amount, nil
+}
+
+func sameAmount(value string, amount int) bool {
+	parsed, err := parseAmount(value)
+	return err == nil && parsed == amount
+}
@@ -0,0 +1,129 @@
+package iso20022_test
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/iso20022"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+const pain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>BATCH-1</MsgId>
+      <CreDtTm>2026-10-01T09:00:00Z</CreDtTm>
+      <NbOfTxs>2</NbOfTxs>
+      <CtrlSum>%s</CtrlSum>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>A</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="XXX">30.00</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>B</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="XXX">100</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>C</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+type report struct {
+	Status       string   `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>GrpSts"`
+	Reason       string   `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>StsRsnInf>Rsn>Cd"`
+	Transactions []string `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts>TxSts"`
+	Reasons      []string `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts>StsRsnInf>Rsn>Cd"`
+}
+
+func execute(t *testing.T, testDB *gorm.DB, document string) report {
+	parsed, err := iso20022.ParsePain001(strings.NewReader(document))
+	require.NoError(t, err)
+
+	raw, err := iso20022.ExecutePain001(testDB, parsed, "treasury", func(string) error { return nil })
+	require.NoError(t, err)
+
+	var result report
+	require.NoError(t, xml.Unmarshal(raw, &result))
+	return result
+}
+
+func TestExecutePain001(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 50}).Error)
+
+	// The second transfer exceeds the balance left by the first one
+	result := execute(t, testDB, strings.Replace(pain001, "%s", "130", 1))
+	require.Equal(t, "PART", result.Status)
+	require.Equal(t, []string{"ACSC", "RJCT"}, result.Transactions)
+	require.Equal(t, []string{"AM04"}, result.Reasons)
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 20, balance)
+
+	// Submitting the same message again returns the first report without executing anything
+	result = execute(t, testDB, strings.Replace(pain001, "%s", "130", 1))
+	require.Equal(t, "PART", result.Status)
+	balance, err = service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 20, balance)
+}
+
+func TestExecutePain001_ResumesInterruptedBatch(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 200}).Error)
+
+	// The first transaction was executed before the process stopped, the report was never written
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	require.NoError(t, testDB.Create(&models.PaymentBatch{Initiator: "treasury", MessageID: "BATCH-1"}).Error)
+	require.NoError(t, testDB.Create(&models.PaymentTransaction{
+		Initiator: "treasury", MessageID: "BATCH-1", EndToEndID: "E2E-1", Status: "ACSC", TransferID: &entry.ID,
+	}).Error)
+
+	result := execute(t, testDB, strings.Replace(pain001, "%s", "130", 1))
+	require.Equal(t, "ACSC", result.Status)
+	require.Equal(t, []string{"ACSC", "ACSC"}, result.Transactions)
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 70, balance)
+}
+
+func TestParsePain001_TooManyTransactions(t *testing.T) {
+	limit := iso20022.MaxTransactions
+	iso20022.MaxTransactions = 1
+	t.Cleanup(func() { iso20022.MaxTransactions = limit })
+
+	_, err := iso20022.ParsePain001(strings.NewReader(strings.Replace(pain001, "%s", "130", 1)))
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+}
+
+func TestExecutePain001_ControlSum(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 500}).Error)
+
+	result := execute(t, testDB, strings.Replace(pain001, "%s", "131", 1))
+	require.Equal(t, "RJCT", result.Status)
+	require.Equal(t, "AM10", result.Reason)
+	require.Empty(t, result.Transactions)
+}
+
+func TestParsePain001_NotPain001(t *testing.T) {
+	_, err := iso20022.ParsePain001(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"/>`))
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+}
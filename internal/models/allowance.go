@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Allowance is the amount the spender may still move out of the owner's wallet with transferFrom
+type Allowance struct {
+	Owner     string `gorm:"primaryKey"`
+	Spender   string `gorm:"primaryKey;index"`
+	Amount    int    `gorm:"not null"`
+	UpdatedAt time.Time
+}
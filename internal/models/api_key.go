@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Role string
+
+const (
+	RoleAdmin Role = "ADMIN"
+	RoleUser  Role = "USER"
+)
+
+// APIKey authenticates a client as the Subject, only the sha256 hash of the key is stored
+type APIKey struct {
+	ID        uint `gorm:"primaryKey"`
+	Name      string
+	Subject   string `gorm:"index"`
+	Role      Role
+	Hash      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditFreeze   AuditAction = "FREEZE"
+	AuditUnfreeze AuditAction = "UNFREEZE"
+	AuditPause    AuditAction = "PAUSE"
+	AuditResume   AuditAction = "RESUME"
+	AuditReverse  AuditAction = "REVERSE"
+)
+
+// AuditRecord is an administrative action taken for compliance or incident reasons
+type AuditRecord struct {
+	ID     uint        `gorm:"primaryKey"`
+	Action AuditAction `gorm:"index"`
+	// Address is the wallet the action applies to, empty for global actions.
+	// For REVERSE it is the sender of the reversed transfer.
+	Address   string `gorm:"index"`
+	Direction FreezeDirection
+	Reason    string
+	// Actor is the subject of the principal who took the action
+	Actor     string
+	CreatedAt time.Time
+}
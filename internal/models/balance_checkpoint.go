@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// BalanceCheckpoint is the ledger-derived balance of a wallet, held balance included, after all entries
+// up to the Sequence number of the changes feed. Checkpoints are written in rounds sharing the same Sequence,
+// a wallet only gets a checkpoint in the rounds covering some of its entries.
+type BalanceCheckpoint struct {
+	Address   string `gorm:"primaryKey"`
+	Sequence  int64  `gorm:"primaryKey;index"`
+	Balance   int
+	CreatedAt time.Time
+}
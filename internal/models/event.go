@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type EventType string
+
+const (
+	// EventWalletImported carries the state of a wallet that existed before the event store
+	EventWalletImported EventType = "WALLET_IMPORTED"
+	EventMinted         EventType = "MINTED"
+	EventBurned         EventType = "BURNED"
+	// EventTransferExecuted moves the amount and the fee out of the sender's wallet, also for reversals and refunds
+	EventTransferExecuted EventType = "TRANSFER_EXECUTED"
+	EventHoldAuthorized   EventType = "HOLD_AUTHORIZED"
+	// EventHoldCaptured transfers the amount out of the sender's held balance, releases the rest and
+	// charges the fee to the sender's available balance
+	EventHoldCaptured EventType = "HOLD_CAPTURED"
+	// EventHoldReleased moves the whole hold back to the sender's available balance, when voided or expired
+	EventHoldReleased EventType = "HOLD_RELEASED"
+	EventFrozen       EventType = "FROZEN"
+	EventUnfrozen     EventType = "UNFROZEN"
+)
+
+// Event is an append-only record of a change to the wallets. The balances, held balances and
+// freezes of the wallets are projections of the events, updated in the same transaction.
+type Event struct {
+	ID        uint      `gorm:"primaryKey"`
+	Type      EventType `gorm:"index"`
+	Data      EventData `gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time
+}
+
+// EventData holds the fields of all event types, each type sets the ones it needs
+type EventData struct {
+	// Address is the wallet of imports and freezes
+	Address    string       `json:"address,omitempty"`
+	From       string       `json:"from,omitempty"`
+	To         string       `json:"to,omitempty"`
+	Amount     int          `json:"amount,omitempty"`
+	Kind       TransferKind `json:"kind,omitempty"`
+	TransferID *uint        `json:"transferId,omitempty"`
+	HoldID     *uint        `json:"holdId,omitempty"`
+	// Fee is paid by the sender to the Treasury on top of the amount
+	Fee      int    `json:"fee,omitempty"`
+	Treasury string `json:"treasury,omitempty"`
+	// Released is the part of a captured hold that went back to the sender
+	Released int `json:"released,omitempty"`
+	// Balance and Held are the balances of an imported wallet
+	Balance       int             `json:"balance,omitempty"`
+	Held          int             `json:"held,omitempty"`
+	Direction     FreezeDirection `json:"direction,omitempty"`
+	SendFrozen    bool            `json:"sendFrozen,omitempty"`
+	ReceiveFrozen bool            `json:"receiveFrozen,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+}
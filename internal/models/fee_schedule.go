@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// FeeTier replaces the flat fee and basis points of the schedule for transfers of at least MinAmount
+type FeeTier struct {
+	MinAmount   int `json:"minAmount"`
+	Flat        int `json:"flat"`
+	BasisPoints int `json:"basisPoints"`
+}
+
+// FeeSchedule is the fee charged to the sender of each transfer on top of the amount.
+// There is a single schedule, stored with ID 1.
+type FeeSchedule struct {
+	ID uint `gorm:"primaryKey"`
+	// Treasury is the wallet receiving the fees, no fees are charged if it's empty
+	Treasury    string
+	Flat        int
+	BasisPoints int
+	// Tiers are sorted by MinAmount, the last tier not above the amount applies
+	Tiers  []FeeTier `gorm:"serializer:json"`
+	MinFee int
+	// MaxFee caps the fee, 0 for no cap
+	MaxFee    int
+	UpdatedAt time.Time
+}
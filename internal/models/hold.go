@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldAuthorized HoldStatus = "AUTHORIZED"
+	HoldCaptured   HoldStatus = "CAPTURED"
+	HoldVoided     HoldStatus = "VOIDED"
+	HoldExpired    HoldStatus = "EXPIRED"
+)
+
+// Hold reserves tokens of the sender for a transfer that is captured or voided later
+type Hold struct {
+	ID          uint   `gorm:"primaryKey"`
+	FromAddress string `gorm:"index"`
+	ToAddress   string
+	Amount      int
+	// CapturedAmount is the part of the amount transferred to the receiver, the rest went back to the sender
+	CapturedAmount int
+	ExpiresAt      time.Time  `gorm:"index"`
+	Status         HoldStatus `gorm:"index"`
+	// TransferID is the ledger entry created by the capture
+	TransferID *uint
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "ASSET"
+	AccountLiability AccountType = "LIABILITY"
+	AccountRevenue   AccountType = "REVENUE"
+)
+
+// Codes of the system accounts
+const (
+	// AccountIssuance is the asset backing all tokens in circulation, debited by mints and credited by burns
+	AccountIssuance = "issuance"
+	// AccountFees is the revenue of the issuer, the fees charged to the senders of transfers
+	AccountFees = "fees"
+	// AccountSuspense holds the tokens reserved by holds until they are captured or released
+	AccountSuspense = "suspense"
+)
+
+// walletAccountPrefix starts the codes of the wallet accounts, followed by the address
+const walletAccountPrefix = "wallet:"
+
+// SystemAccounts is the part of the chart of accounts that exists independently of the wallets
+var SystemAccounts = []Account{
+	{Code: AccountIssuance, Name: "Tokens issued", Type: AccountAsset},
+	{Code: AccountFees, Name: "Fee revenue", Type: AccountRevenue},
+	{Code: AccountSuspense, Name: "Held tokens", Type: AccountLiability},
+}
+
+// Account is an entry of the chart of accounts
+type Account struct {
+	Code string      `gorm:"primaryKey" json:"code"`
+	Name string      `json:"name"`
+	Type AccountType `gorm:"not null" json:"type"`
+	// Address is the wallet of a wallet account, empty for system accounts
+	Address   string    `gorm:"index" json:"address,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WalletAccount is the account of the tokens the issuer owes to the holder of the wallet
+func WalletAccount(address string) Account {
+	return Account{Code: walletAccountPrefix + address, Name: "Wallet " + address, Type: AccountLiability, Address: address}
+}
+
+// JournalEntry records an operation as postings that sum to zero
+type JournalEntry struct {
+	ID uint `gorm:"primaryKey"`
+	// EventID is the event the entry was posted for, nil for the opening entry
+	EventID     *uint `gorm:"index"`
+	TransferID  *uint `gorm:"index"`
+	HoldID      *uint `gorm:"index"`
+	Description string
+	Postings    []JournalPosting `gorm:"foreignKey:EntryID"`
+	CreatedAt   time.Time        `gorm:"index"`
+}
+
+// JournalPosting debits an account with a positive amount or credits it with a negative one
+type JournalPosting struct {
+	ID          uint   `gorm:"primaryKey"`
+	EntryID     uint   `gorm:"not null;index"`
+	AccountCode string `gorm:"not null;index"`
+	Amount      int    `gorm:"not null"`
+}
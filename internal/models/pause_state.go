@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// PauseState tells whether all balance changes are halted. There is a single state, stored with ID 1.
+type PauseState struct {
+	ID        uint `gorm:"primaryKey"`
+	Paused    bool `gorm:"not null;default:false"`
+	Reason    string
+	Actor     string
+	UpdatedAt time.Time
+}
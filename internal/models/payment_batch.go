@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// PaymentBatch is a pain.001 credit transfer initiation, recorded by its message ID so that a file
+// submitted twice is only executed once
+type PaymentBatch struct {
+	// Initiator is the subject who submitted the file, message IDs are only unique per initiator
+	Initiator string `gorm:"primaryKey"`
+	MessageID string `gorm:"primaryKey"`
+	// Report is the pain.002 status report, empty while the batch is being executed or if its execution
+	// was interrupted
+	Report    []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PaymentTransaction is the outcome of a credit transfer of a PaymentBatch. An accepted transaction is
+// recorded in the same DB transaction as its transfer, so an interrupted batch is resumed with only the
+// transactions not executed yet.
+type PaymentTransaction struct {
+	Initiator string `gorm:"primaryKey"`
+	MessageID string `gorm:"primaryKey"`
+	// Position is the index of the transaction in the document, end-to-end IDs need not be unique
+	Position   int `gorm:"primaryKey;autoIncrement:false"`
+	EndToEndID string
+	// Status is the ISO 20022 transaction status, ACSC or RJCT
+	Status string `gorm:"not null"`
+	// ReasonCode and ReasonInfo explain a rejection
+	ReasonCode string
+	ReasonInfo string
+	// TransferID is the ledger entry of an accepted transaction
+	TransferID *uint
+	CreatedAt  time.Time
+}
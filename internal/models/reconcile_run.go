@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Discrepancy is a wallet whose balance doesn't match the balance derived from the ledger
+type Discrepancy struct {
+	Address       string `json:"address"`
+	Balance       int    `json:"balance"`
+	LedgerBalance int    `json:"ledgerBalance"`
+}
+
+// ReconcileRun is the recorded result of a check of the balances against the ledger
+type ReconcileRun struct {
+	ID uint `gorm:"primaryKey"`
+	OK bool `gorm:"index"`
+	// TotalSupply is the minted minus the burned amount
+	TotalSupply int
+	// TotalBalance is the sum of all wallet balances, including held balances and the slots of sharded wallets
+	TotalBalance     int
+	Discrepancies    []Discrepancy `gorm:"serializer:json"`
+	NegativeBalances []string      `gorm:"serializer:json"`
+	// Paused tells whether the run paused transfers in strict mode
+	Paused    bool
+	CreatedAt time.Time `gorm:"index"`
+}
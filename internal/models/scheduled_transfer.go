@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type ScheduledStatus string
+
+const (
+	ScheduledPending   ScheduledStatus = "SCHEDULED"
+	ScheduledCompleted ScheduledStatus = "COMPLETED"
+	ScheduledFailed    ScheduledStatus = "FAILED"
+	ScheduledCancelled ScheduledStatus = "CANCELLED"
+)
+
+// ScheduledTransfer is an instruction to transfer tokens at ExecuteAt
+type ScheduledTransfer struct {
+	ID          uint   `gorm:"primaryKey"`
+	FromAddress string `gorm:"index"`
+	ToAddress   string
+	Amount      int
+	ExecuteAt   time.Time       `gorm:"index"`
+	Status      ScheduledStatus `gorm:"index"`
+	// Reason holds the error of a failed transfer
+	Reason string
+	// TransferID is the ledger entry created by the executed transfer
+	TransferID *uint
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
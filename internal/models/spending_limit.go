@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SpendingLimit caps what a wallet can send, the limit with an empty Address applies to every wallet.
+// Zero fields are not limited.
+type SpendingLimit struct {
+	Address string `gorm:"primaryKey"`
+	// PerTransfer is the maximum amount of a single transfer
+	PerTransfer int
+	// Daily and Monthly are the maximum amounts sent in the rolling 24 hours and 30 days
+	Daily   int
+	Monthly int
+	// PerMinute is the maximum number of transfers sent in the rolling minute
+	PerMinute int
+	UpdatedAt time.Time
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "ACTIVE"
+	StandingOrderSuspended StandingOrderStatus = "SUSPENDED"
+	StandingOrderFinished  StandingOrderStatus = "FINISHED"
+	StandingOrderCancelled StandingOrderStatus = "CANCELLED"
+)
+
+// FailurePolicy decides what happens to a standing order run failing for an insufficient balance
+type FailurePolicy string
+
+const (
+	// PolicySkip gives up the run and waits for the next one
+	PolicySkip FailurePolicy = "SKIP"
+	// PolicyRetry retries the run MaxRetries times every RetryInterval, then gives it up
+	PolicyRetry FailurePolicy = "RETRY"
+	// PolicySuspend suspends the order until it is resumed
+	PolicySuspend FailurePolicy = "SUSPEND"
+)
+
+// StandingOrder is an instruction to transfer tokens repeatedly, on a cron schedule or at a fixed interval
+type StandingOrder struct {
+	ID          uint   `gorm:"primaryKey"`
+	FromAddress string `gorm:"index"`
+	ToAddress   string
+	Amount      int
+	// Cron is a standard cron expression like "0 9 * * MON", Interval is used when it's empty
+	Cron     string
+	Interval time.Duration
+	// EndAt is the time after which no more runs happen
+	EndAt *time.Time
+	// MaxRuns is the maximum number of runs, completed or given up, 0 for no limit
+	MaxRuns int
+	Runs    int `gorm:"not null;default:0"`
+	// OnInsufficientBalance is the policy applied when a run fails for an insufficient balance.
+	// Runs failing for any other reason suspend the order.
+	OnInsufficientBalance FailurePolicy
+	MaxRetries            int
+	RetryInterval         time.Duration
+	// Retries is the number of failed attempts of the current run
+	Retries int `gorm:"not null;default:0"`
+	// DueAt is the scheduled time of the current run, NextRunAt is when it is attempted (again)
+	DueAt     time.Time
+	NextRunAt time.Time           `gorm:"index"`
+	Status    StandingOrderStatus `gorm:"index"`
+	// LastError is the error of the last failed attempt
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type TransferKind string
+
+const (
+	KindTransfer TransferKind = "TRANSFER"
+	// KindMint creates new tokens, it has no sender
+	KindMint TransferKind = "MINT"
+	// KindBurn destroys tokens, it has no receiver
+	KindBurn TransferKind = "BURN"
+	// KindFee is the fee of the ParentID transfer, paid by its sender to the treasury
+	KindFee TransferKind = "FEE"
+	// KindReversal returns the whole remaining amount of the ParentID transfer to its sender
+	KindReversal TransferKind = "REVERSAL"
+	// KindRefund returns part or all of the ParentID transfer to its sender
+	KindRefund TransferKind = "REFUND"
+)
+
+type TransferStatus string
+
+const (
+	TransferPending   TransferStatus = "PENDING"
+	TransferCompleted TransferStatus = "COMPLETED"
+	TransferFailed    TransferStatus = "FAILED"
+	// The tokens of a completed transfer were sent back by REVERSAL or REFUND entries
+	TransferReversed          TransferStatus = "REVERSED"
+	TransferPartiallyRefunded TransferStatus = "PARTIALLY_REFUNDED"
+	TransferRefunded          TransferStatus = "REFUNDED"
+)
+
+// SettledStatuses are the statuses of the entries whose tokens moved
+var SettledStatuses = []TransferStatus{TransferCompleted, TransferReversed, TransferPartiallyRefunded, TransferRefunded}
+
+// Transfer is a ledger entry of tokens moved between two wallets
+type Transfer struct {
+	ID          uint         `gorm:"primaryKey"`
+	Kind        TransferKind `gorm:"not null;default:'TRANSFER'"`
+	FromAddress string       `gorm:"index"`
+	ToAddress   string       `gorm:"index"`
+	Amount      int
+	Status      TransferStatus `gorm:"index"`
+	// Reason holds the error of a failed transfer, or why a transfer was reversed
+	Reason string
+	// Fee is the fee charged to the sender on top of the amount, recorded as its own FEE entry
+	Fee int `gorm:"not null;default:0"`
+	// ParentID is the transfer a FEE entry was charged for, or a REVERSAL or REFUND entry sent back
+	ParentID *uint `gorm:"index"`
+	// Refunded is the part of the amount already sent back by REVERSAL and REFUND entries
+	Refunded int `gorm:"not null;default:0"`
+	// Spender moved the tokens out of the sender's wallet using an allowance, empty for the sender itself
+	Spender string
+	// StandingOrderID links the runs of a standing order to it
+	StandingOrderID *uint `gorm:"index"`
+	// SettledXID is the ID of the DB transaction that settled the entry, sequence numbers follow it
+	SettledXID *int64 `gorm:"column:settled_xid;default:(pg_current_xact_id()::text)::bigint;index:idx_transfers_unsequenced,where:sequence IS NULL AND status <> 'FAILED'"`
+	// Sequence is the position of a settled entry in the changes feed, assigned shortly after it is committed
+	Sequence *int64 `gorm:"uniqueIndex"`
+	// SequencedAt is when the Sequence was assigned, it increases with the Sequence
+	SequencedAt *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -1,6 +1,28 @@
 package models
 
+// FreezeDirection is the side of transfers a freeze applies to
+type FreezeDirection string
+
+const (
+	FreezeSend    FreezeDirection = "SEND"
+	FreezeReceive FreezeDirection = "RECEIVE"
+	FreezeBoth    FreezeDirection = "BOTH"
+)
+
 type Wallet struct {
 	Address string `gorm:"primaryKey"`
+	// Balance is the available balance, tokens reserved by holds are moved to Held
 	Balance int
+	Held    int `gorm:"not null;default:0"`
+	// Version is bumped on every balance change and used by the optimistic strategy
+	Version int `gorm:"not null;default:0"`
+	// Shards is the number of WalletShard slots holding the balance, 0 if the wallet is not sharded
+	Shards int `gorm:"not null;default:0"`
+	// Owner is the subject allowed to move tokens out of the wallet, only admins can move them if empty
+	Owner string `gorm:"index"`
+	// SendFrozen wallets can't send tokens and ReceiveFrozen wallets can't receive them
+	SendFrozen    bool `gorm:"not null;default:false"`
+	ReceiveFrozen bool `gorm:"not null;default:false"`
+	// FreezeReason is the reason of the latest freeze, empty when the wallet is not frozen
+	FreezeReason string
 }
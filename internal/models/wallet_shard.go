@@ -0,0 +1,8 @@
+package models
+
+// WalletShard is one slot of a sharded wallet, the wallet's balance is the sum of all its slots
+type WalletShard struct {
+	Address string `gorm:"primaryKey"`
+	Slot    int    `gorm:"primaryKey;autoIncrement:false"`
+	Balance int
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// OutboxEvent is an event written in the same DB transaction as the change it describes,
+// webhook deliveries are fanned out from it after the commit
+type OutboxEvent struct {
+	ID   uint   `gorm:"primaryKey"`
+	Type string `gorm:"index"`
+	// Payload is the JSON encoded data of the event
+	Payload []byte
+	// DispatchedAt is when deliveries were created for the matching subscriptions, nil until then
+	DispatchedAt *time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
+
+// WebhookSubscription receives the events of the given types as signed POST requests to its URL
+type WebhookSubscription struct {
+	ID  uint `gorm:"primaryKey"`
+	URL string
+	// EventTypes are the event types delivered to the URL, "*" matches all of them
+	EventTypes []string `gorm:"serializer:json"`
+	// Secret is the key of the HMAC signature of every delivery
+	Secret    string
+	Active    bool `gorm:"not null;default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "PENDING"
+	DeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	// DeliveryDead gave up after the maximum number of attempts, until an admin replays it
+	DeliveryDead WebhookDeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery is an event to be sent to a subscription, retried with an exponential backoff
+type WebhookDelivery struct {
+	ID             uint `gorm:"primaryKey"`
+	SubscriptionID uint `gorm:"index"`
+	EventID        uint `gorm:"index"`
+	Event          OutboxEvent
+	Status         WebhookDeliveryStatus `gorm:"index"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	// NextAttemptAt is when the delivery is due, a worker sending it pushes it back while the request is in flight
+	NextAttemptAt time.Time `gorm:"index"`
+	// LastError is why the last attempt failed, ResponseStatus the HTTP status of its response if there was one
+	LastError      string
+	ResponseStatus int
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
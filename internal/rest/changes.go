@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+// maxChangesWait is the longest a long-polling request for changes waits for the first entry
+const maxChangesWait = time.Minute
+
+// heartbeatInterval is how often an idle event stream sends a comment, so proxies keep it open
+const heartbeatInterval = 15 * time.Second
+
+type changePage struct {
+	Changes []transfer `json:"changes"`
+	// LastSequence is the since parameter of the next request, unchanged if there were no changes
+	LastSequence int64 `json:"lastSequence"`
+}
+
+// getChanges returns the settled ledger entries after the since sequence number to admins. With wait,
+// the request waits for the first entry, and with Accept: text/event-stream the entries are streamed
+// as server-sent events whose IDs are their sequence numbers.
+func (h *handler) getChanges(w http.ResponseWriter, r *http.Request) {
+	if !auth.FromContext(r.Context()).HasRole(models.RoleAdmin) {
+		writeError(w, fmt.Errorf("%w: the changes feed is only available to admins", auth.ErrForbidden))
+		return
+	}
+
+	query := r.URL.Query()
+
+	// A reconnecting event source sends the ID of the last event it received
+	cursor := query.Get("since")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+	var since int64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid since")
+			return
+		}
+	}
+
+	limit := defaultPageSize
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamChanges(w, r, since, limit)
+		return
+	}
+
+	var wait time.Duration
+	if value := query.Get("wait"); value != "" {
+		var err error
+		if wait, err = time.ParseDuration(value); err != nil || wait < 0 || wait > maxChangesWait {
+			writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument,
+				fmt.Sprintf("wait must be a duration between 0 and %s", maxChangesWait))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	transfers, err := service.WaitForChanges(ctx, h.db.WithContext(r.Context()), "", since, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page := changePage{Changes: make([]transfer, len(transfers)), LastSequence: since}
+	for i := range transfers {
+		page.Changes[i] = toTransfer(&transfers[i])
+		page.LastSequence = *transfers[i].Sequence
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// streamChanges sends the entries as server-sent events until the client disconnects
+func (h *handler) streamChanges(w http.ResponseWriter, r *http.Request, since int64, limit int) {
+	db := h.db.WithContext(r.Context())
+
+	// Check the arguments before the stream starts
+	if _, err := service.Changes(db, "", since, 1); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+
+	for {
+		ctx, cancel := context.WithTimeout(r.Context(), heartbeatInterval)
+		transfers, err := service.WaitForChanges(ctx, db, "", since, limit)
+		cancel()
+		if r.Context().Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to stream changes: %v", err)
+			return
+		}
+
+		if len(transfers) == 0 {
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		for i := 0; i < len(transfers) && err == nil; i++ {
+			var data []byte
+			if data, err = json.Marshal(toTransfer(&transfers[i])); err != nil {
+				break
+			}
+			since = *transfers[i].Sequence
+			_, err = fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", since, data)
+		}
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			log.Printf("Failed to stream changes: %v", err)
+			return
+		}
+	}
+}
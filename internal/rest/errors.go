@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"token-transfer-api/internal/service"
+)
+
+type problem struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// statuses maps the codes of the service layer to HTTP statuses
+var statuses = map[service.Code]int{
+	service.CodeInvalidArgument:     http.StatusBadRequest,
+	service.CodeForbidden:           http.StatusForbidden,
+	service.CodeWalletNotFound:      http.StatusNotFound,
+	service.CodeWalletFrozen:        http.StatusConflict,
+	service.CodeAddressBlocked:      http.StatusForbidden,
+	service.CodePaused:              http.StatusServiceUnavailable,
+	service.CodeConflict:            http.StatusConflict,
+	service.CodeInsufficientBalance: http.StatusUnprocessableEntity,
+	service.CodeLimitExceeded:       http.StatusUnprocessableEntity,
+}
+
+// writeError maps the error of the service layer to the HTTP status and error code of the response.
+// Unexpected errors are only logged, the client gets a generic message.
+func writeError(w http.ResponseWriter, err error) {
+	code := service.Classify(err)
+	status, ok := statuses[code]
+	if !ok {
+		log.Printf("Request failed: %v", err)
+		writeProblem(w, http.StatusInternalServerError, service.CodeInternal, "internal error")
+		return
+	}
+	writeProblem(w, status, code, err.Error())
+}
+
+func writeProblem(w http.ResponseWriter, status int, code service.Code, message string) {
+	var body problem
+	body.Error.Code = string(code)
+	body.Error.Message = message
+	writeJSON(w, status, body)
+}
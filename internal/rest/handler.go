@@ -0,0 +1,295 @@
+package rest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/iso20022"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+	"token-transfer-api/internal/statement"
+)
+
+// defaultPageSize is the number of transfers listed when the limit parameter is missing
+const defaultPageSize = 50
+
+// maxPaymentsSize is the maximum size of a pain.001 document
+const maxPaymentsSize = 10 << 20
+
+//go:embed openapi.json
+var openAPI []byte
+
+type handler struct {
+	db *gorm.DB
+}
+
+// NewHandler serves the REST API under /v1, backed by the same service functions as the GraphQL API
+func NewHandler(db *gorm.DB) http.Handler {
+	h := &handler{db: db}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/transfers", h.createTransfer)
+	mux.HandleFunc("GET /v1/transfers", h.listTransfers)
+	mux.HandleFunc("GET /v1/wallets/{address}", h.getWallet)
+	mux.HandleFunc("GET /v1/wallets/{address}/statement", h.getStatement)
+	mux.HandleFunc("POST /v1/payments", h.createPayments)
+	mux.HandleFunc("GET /v1/changes", h.getChanges)
+	mux.HandleFunc("GET /v1/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPI)
+	})
+	return mux
+}
+
+type transferRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+type transferResult struct {
+	Balance int `json:"balance"`
+	Fee     int `json:"fee"`
+}
+
+type wallet struct {
+	Address string `json:"address"`
+	Owner   string `json:"owner,omitempty"`
+	// SendFrozen and ReceiveFrozen tell in which direction a frozen wallet is frozen
+	SendFrozen    bool `json:"sendFrozen"`
+	ReceiveFrozen bool `json:"receiveFrozen"`
+	Balance       int  `json:"balance"`
+	Held          int  `json:"held"`
+	Shards        int  `json:"shards"`
+}
+
+type transfer struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Amount   int    `json:"amount"`
+	Fee      int    `json:"fee"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+	Refunded int    `json:"refunded"`
+	ParentID string `json:"parentId,omitempty"`
+	// Sequence is the position in the changes feed, missing until the entry is numbered
+	Sequence  *int64    `json:"sequence,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type transferPage struct {
+	Transfers []transfer `json:"transfers"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (h *handler) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var req transferRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := auth.AuthorizeSpend(r.Context(), h.db, req.From); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	entry, balance, err := service.TransferRecorded(h.db.WithContext(r.Context()), req.From, req.To, req.Amount)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transferResult{Balance: balance, Fee: entry.Fee})
+}
+
+func (h *handler) getWallet(w http.ResponseWriter, r *http.Request) {
+	found, err := service.Wallet(h.db.WithContext(r.Context()), r.PathValue("address"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wallet{
+		Address:       found.Address,
+		Owner:         found.Owner,
+		SendFrozen:    found.SendFrozen,
+		ReceiveFrozen: found.ReceiveFrozen,
+		Balance:       found.Balance,
+		Held:          found.Held,
+		Shards:        found.Shards,
+	})
+}
+
+// getStatement streams the statement of the wallet, only to whoever may spend from it
+func (h *handler) getStatement(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	address := r.PathValue("address")
+
+	format := query.Get("format")
+	if format == "" {
+		format = statement.FormatCSV
+	}
+	contentType := statement.ContentType(format)
+	if contentType == "" {
+		writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "format must be csv, ndjson, beancount or camt053")
+		return
+	}
+	from, err := parseDate(query.Get("from"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid from: "+err.Error())
+		return
+	}
+	to, err := parseDate(query.Get("to"))
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid to: "+err.Error())
+		return
+	}
+
+	if err := auth.AuthorizeSpend(r.Context(), h.db, address); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	db := h.db.WithContext(r.Context())
+	opened, err := service.OpenStatement(db, address, from, to)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q",
+		fmt.Sprintf("statement-%s-%s.%s", address, from.Format(time.DateOnly), extensions[format])))
+	// The status is sent with the first row, later errors can only cut the download short
+	if err := statement.Write(db, w, format, opened); err != nil {
+		log.Printf("Failed to write statement: %v", err)
+	}
+}
+
+// extensions are the file name extensions of the statement formats
+var extensions = map[string]string{
+	statement.FormatCSV:       "csv",
+	statement.FormatNDJSON:    "ndjson",
+	statement.FormatBeancount: "beancount",
+	statement.FormatCamt053:   "xml",
+}
+
+// createPayments executes a pain.001 credit transfer initiation and answers with the pain.002 status report.
+// The principal must be allowed to spend from the debtor accounts, unauthorized transfers are rejected.
+func (h *handler) createPayments(w http.ResponseWriter, r *http.Request) {
+	document, err := iso20022.ParsePain001(http.MaxBytesReader(w, r.Body, maxPaymentsSize))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	if principal == nil {
+		writeError(w, auth.ErrForbidden)
+		return
+	}
+
+	db := h.db.WithContext(r.Context())
+	report, err := iso20022.ExecutePain001(db, document, principal.Subject, func(debtor string) error {
+		return auth.AuthorizeSpend(r.Context(), h.db, debtor)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	if _, err := w.Write(report); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// parseDate accepts RFC 3339 times and plain dates, which start at midnight UTC
+func parseDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (h *handler) listTransfers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := defaultPageSize
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	// The cursor is the ID of the last transfer of the previous page, clients treat it as opaque
+	var before uint64
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		if before, err = strconv.ParseUint(cursor, 10, 64); err != nil || before == 0 {
+			writeProblem(w, http.StatusBadRequest, service.CodeInvalidArgument, "invalid cursor")
+			return
+		}
+	}
+
+	transfers, err := service.ListTransfers(h.db.WithContext(r.Context()), query.Get("address"), uint(before), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page := transferPage{Transfers: make([]transfer, len(transfers))}
+	for i := range transfers {
+		page.Transfers[i] = toTransfer(&transfers[i])
+	}
+	if len(transfers) == limit {
+		page.NextCursor = page.Transfers[limit-1].ID
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func toTransfer(t *models.Transfer) transfer {
+	result := transfer{
+		ID:        strconv.FormatUint(uint64(t.ID), 10),
+		Kind:      string(t.Kind),
+		From:      t.FromAddress,
+		To:        t.ToAddress,
+		Amount:    t.Amount,
+		Fee:       t.Fee,
+		Status:    string(t.Status),
+		Reason:    t.Reason,
+		Refunded:  t.Refunded,
+		Sequence:  t.Sequence,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+	if t.ParentID != nil {
+		result.ParentID = strconv.FormatUint(uint64(*t.ParentID), 10)
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
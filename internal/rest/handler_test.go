@@ -0,0 +1,187 @@
+package rest_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/rest"
+	"token-transfer-api/internal/service"
+)
+
+// serve sends the request to the handler on behalf of the principal and decodes the JSON response
+func serve(t *testing.T, handler http.Handler, principal *auth.Principal, method string, target string, body string, resp any) int {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), resp))
+	return rec.Code
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	code := serve(t, rest.NewHandler(nil), nil, http.MethodGet, "/v1/openapi.json", "", &doc)
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/v1/transfers")
+	require.Contains(t, doc.Paths, "/v1/wallets/{address}")
+}
+
+func TestCreateTransfer(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	handler := rest.NewHandler(testDB)
+	admin := &auth.Principal{Subject: "admin", Role: models.RoleAdmin}
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10, Owner: "alice"}).Error)
+
+	var result struct{ Balance int }
+	code := serve(t, handler, admin, http.MethodPost, "/v1/transfers", `{"from": "A", "to": "B", "amount": 4}`, &result)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 6, result.Balance)
+
+	var wallet struct {
+		Address string
+		Balance int
+	}
+	code = serve(t, handler, admin, http.MethodGet, "/v1/wallets/B", "", &wallet)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 4, wallet.Balance)
+
+	errorCases := []struct {
+		name      string
+		principal *auth.Principal
+		body      string
+		status    int
+		code      string
+	}{
+		{"invalid body", admin, `{"from": "A", "to": "B", "amount": "4"}`, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"invalid amount", admin, `{"from": "A", "to": "B", "amount": 0}`, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"insufficient balance", admin, `{"from": "A", "to": "B", "amount": 100}`, http.StatusUnprocessableEntity, "INSUFFICIENT_BALANCE"},
+		{"sender not found", admin, `{"from": "C", "to": "B", "amount": 1}`, http.StatusNotFound, "WALLET_NOT_FOUND"},
+		{"not the owner", &auth.Principal{Subject: "bob", Role: models.RoleUser}, `{"from": "A", "to": "B", "amount": 1}`, http.StatusForbidden, "FORBIDDEN"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp errorResponse
+			code := serve(t, handler, tc.principal, http.MethodPost, "/v1/transfers", tc.body, &resp)
+			require.Equal(t, tc.status, code)
+			require.Equal(t, tc.code, resp.Error.Code)
+		})
+	}
+}
+
+func TestListTransfers_Cursor(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	handler := rest.NewHandler(testDB)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	for _, to := range []string{"B", "C", "D"} {
+		_, err := service.Transfer(testDB, "A", to, 1)
+		require.NoError(t, err)
+	}
+
+	type page struct {
+		Transfers []struct {
+			ID string
+			To string
+		}
+		NextCursor string
+	}
+
+	var first page
+	code := serve(t, handler, nil, http.MethodGet, "/v1/transfers?address=A&limit=2", "", &first)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, first.Transfers, 2)
+	require.Equal(t, "D", first.Transfers[0].To)
+	require.NotEmpty(t, first.NextCursor)
+
+	var second page
+	code = serve(t, handler, nil, http.MethodGet, "/v1/transfers?address=A&limit=2&cursor="+first.NextCursor, "", &second)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, second.Transfers, 1)
+	require.Equal(t, "B", second.Transfers[0].To)
+	require.Empty(t, second.NextCursor)
+}
+
+func TestGetStatement(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	handler := rest.NewHandler(testDB)
+	admin := &auth.Principal{Subject: "admin", Role: models.RoleAdmin}
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	_, err = service.AssignSequences(testDB, 10)
+	require.NoError(t, err)
+
+	from := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	to := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	req := httptest.NewRequest(http.MethodGet, "/v1/wallets/A/statement?format=csv&from="+from+"&to="+to, nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), admin))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	require.Equal(t, []string{"MINT", "", "0", "100", "100"}, rows[2][2:])
+	require.Equal(t, []string{"TRANSFER", "B", "30", "0", "70"}, rows[3][2:])
+	require.Equal(t, []string{"CLOSING", "", "", "", "70"}, rows[4][2:])
+}
+
+func TestGetChanges(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	handler := rest.NewHandler(testDB)
+	admin := &auth.Principal{Subject: "admin", Role: models.RoleAdmin}
+
+	_, err := service.Mint(testDB, "A", 10)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 4)
+	require.NoError(t, err)
+	_, err = service.AssignSequences(testDB, 10)
+	require.NoError(t, err)
+
+	var page struct {
+		Changes []struct {
+			Kind     string
+			Sequence int64
+		}
+		LastSequence int64
+	}
+	code := serve(t, handler, admin, http.MethodGet, "/v1/changes?since=1&wait=1s", "", &page)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page.Changes, 1)
+	require.Equal(t, "TRANSFER", page.Changes[0].Kind)
+	require.Equal(t, int64(2), page.LastSequence)
+
+	var failure errorResponse
+	user := &auth.Principal{Subject: "alice", Role: models.RoleUser}
+	code = serve(t, handler, user, http.MethodGet, "/v1/changes", "", &failure)
+	require.Equal(t, http.StatusForbidden, code)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// Approve sets the amount the spender may move out of the owner's wallet, replacing the previous allowance
+func Approve(db *gorm.DB, owner string, spender string, amount int) (*models.Allowance, error) {
+	if err := validateAllowance(owner, spender, amount); err != nil {
+		return nil, err
+	}
+
+	allowance := &models.Allowance{Owner: owner, Spender: spender, Amount: amount}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "spender"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+	}).Create(allowance).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve allowance: %w", err)
+	}
+	return allowance, nil
+}
+
+// IncreaseAllowance adds the amount to the spender's allowance
+func IncreaseAllowance(db *gorm.DB, owner string, spender string, amount int) (*models.Allowance, error) {
+	if amount <= 0 {
+		return nil, invalidArgument("allowance change must be greater than 0")
+	}
+	return changeAllowance(db, owner, spender, amount)
+}
+
+// DecreaseAllowance subtracts the amount from the spender's allowance, which can't go below zero
+func DecreaseAllowance(db *gorm.DB, owner string, spender string, amount int) (*models.Allowance, error) {
+	if amount <= 0 {
+		return nil, invalidArgument("allowance change must be greater than 0")
+	}
+	return changeAllowance(db, owner, spender, -amount)
+}
+
+// Allowance returns the amount the spender may still move out of the owner's wallet
+func Allowance(db *gorm.DB, owner string, spender string) (int, error) {
+	var allowance models.Allowance
+	err := db.First(&allowance, "owner = ? AND spender = ?", owner, spender).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load allowance: %w", err)
+	}
+	return allowance.Amount, nil
+}
+
+// TransferFrom moves the tokens out of the owner's wallet on behalf of the spender and deducts them
+// from the spender's allowance. The wallets are locked the same way as by Transfer, after the allowance.
+// It returns the completed ledger entry and the new balance of the owner.
+func TransferFrom(db *gorm.DB, spender string, from string, to string, amount int) (*models.Transfer, int, error) {
+	if err := validate(from, to, amount); err != nil {
+		return nil, 0, err
+	}
+
+	entry := &models.Transfer{
+		Kind:        models.KindTransfer,
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount,
+		Spender:     spender,
+	}
+	var updatedBalance int
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		allowance, err := lockAllowance(tx, from, spender)
+		if err != nil {
+			return err
+		}
+		if allowance.Amount < amount {
+			return fmt.Errorf("%w: required %d, available %d", ErrInsufficientAllowance, amount, allowance.Amount)
+		}
+
+		err = tx.Model(allowance).Update("amount", gorm.Expr("amount - ?", amount)).Error
+		if err != nil {
+			return fmt.Errorf("failed to update allowance: %w", err)
+		}
+
+		updatedBalance, err = execute(tx, DefaultStrategy, entry)
+		return err
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+	return entry, updatedBalance, nil
+}
+
+func changeAllowance(db *gorm.DB, owner string, spender string, delta int) (*models.Allowance, error) {
+	if err := validateAllowance(owner, spender, 0); err != nil {
+		return nil, err
+	}
+
+	var allowance *models.Allowance
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Make sure there is a row to lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Allowance{Owner: owner, Spender: spender}).Error
+		if err != nil {
+			return fmt.Errorf("failed to create allowance: %w", err)
+		}
+
+		if allowance, err = lockAllowance(tx, owner, spender); err != nil {
+			return err
+		}
+		if allowance.Amount+delta < 0 {
+			return invalidArgument("allowance of %s would go below zero, it is %d", spender, allowance.Amount)
+		}
+
+		allowance.Amount += delta
+		if err := tx.Save(allowance).Error; err != nil {
+			return fmt.Errorf("failed to update allowance: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return allowance, nil
+}
+
+func lockAllowance(tx *gorm.DB, owner string, spender string) (*models.Allowance, error) {
+	var allowances []models.Allowance
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("owner = ? AND spender = ?", owner, spender).
+		Limit(1).
+		Find(&allowances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock allowance: %w", err)
+	}
+	if len(allowances) == 0 {
+		return &models.Allowance{Owner: owner, Spender: spender}, nil
+	}
+	return &allowances[0], nil
+}
+
+func validateAllowance(owner string, spender string, amount int) error {
+	if amount < 0 {
+		return invalidArgument("allowance must not be negative")
+	}
+	if owner == spender {
+		return invalidArgument("owner and spender must be different wallets")
+	}
+	return nil
+}
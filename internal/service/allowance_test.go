@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestTransferFrom(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+
+	_, err := service.Approve(testDB, "A", "S", 10)
+	require.NoError(t, err)
+	_, err = service.IncreaseAllowance(testDB, "A", "S", 5)
+	require.NoError(t, err)
+	_, err = service.DecreaseAllowance(testDB, "A", "S", 3)
+	require.NoError(t, err)
+
+	allowance, err := service.Allowance(testDB, "A", "S")
+	require.NoError(t, err)
+	require.Equal(t, 12, allowance)
+
+	entry, balance, err := service.TransferFrom(testDB, "S", "A", "B", 8)
+	require.NoError(t, err)
+	require.Equal(t, 92, balance)
+	require.Equal(t, "S", entry.Spender)
+
+	_, _, err = service.TransferFrom(testDB, "S", "A", "B", 5)
+	require.ErrorIs(t, err, service.ErrInsufficientAllowance)
+
+	// Allowances are per owner and spender
+	_, _, err = service.TransferFrom(testDB, "T", "A", "B", 1)
+	require.ErrorIs(t, err, service.ErrInsufficientAllowance)
+
+	allowance, err = service.Allowance(testDB, "A", "S")
+	require.NoError(t, err)
+	require.Equal(t, 4, allowance)
+
+	_, err = service.DecreaseAllowance(testDB, "A", "S", 5)
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+}
+
+func TestTransferFrom_InsufficientBalanceKeepsAllowance(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1}).Error)
+	_, err := service.Approve(testDB, "A", "S", 10)
+	require.NoError(t, err)
+
+	_, _, err = service.TransferFrom(testDB, "S", "A", "B", 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "insufficient balance")
+
+	allowance, err := service.Allowance(testDB, "A", "S")
+	require.NoError(t, err)
+	require.Equal(t, 10, allowance)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// blocklist is the set of lowercase addresses refused on either side of a transfer
+var blocklist atomic.Pointer[map[string]struct{}]
+
+// LoadBlocklist replaces the blocklist with the addresses in the file, one per line.
+// Empty lines and lines starting with # are ignored, addresses are compared case-insensitively.
+func LoadBlocklist(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	addresses := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addresses[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	blocklist.Store(&addresses)
+	return len(addresses), nil
+}
+
+// WatchBlocklist reloads the blocklist whenever the file changes, until the context is cancelled.
+// The previous blocklist stays in place if the file can't be read.
+func WatchBlocklist(ctx context.Context, path string, interval time.Duration) {
+	go func() {
+		var modified time.Time
+		if info, err := os.Stat(path); err == nil {
+			modified = info.ModTime()
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Printf("Failed to check blocklist: %v", err)
+				continue
+			}
+			if info.ModTime().Equal(modified) {
+				continue
+			}
+
+			count, err := LoadBlocklist(path)
+			if err != nil {
+				log.Printf("Failed to reload blocklist: %v", err)
+				continue
+			}
+			modified = info.ModTime()
+			log.Printf("Reloaded blocklist with %d addresses", count)
+		}
+	}()
+}
+
+// Blocked reports whether the address is on the blocklist
+func Blocked(address string) bool {
+	addresses := blocklist.Load()
+	if addresses == nil {
+		return false
+	}
+	_, ok := (*addresses)[strings.ToLower(address)]
+	return ok
+}
@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"token-transfer-api/internal/service"
+)
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# sanctioned\n0xABC\n\n  0xdef  \n"), 0o600))
+
+	count, err := service.LoadBlocklist(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		_, err := service.LoadBlocklist(path)
+		require.NoError(t, err)
+	})
+
+	require.Equal(t, 2, count)
+	require.True(t, service.Blocked("0xabc"))
+	require.True(t, service.Blocked("0xDEF"))
+	require.False(t, service.Blocked("0x123"))
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// currentXID is the ID of the current DB transaction as a bigint
+const currentXID = "(pg_current_xact_id()::text)::bigint"
+
+// sequencerLockKey is the advisory lock held while assigning sequence numbers, one sequencer runs at a time
+const sequencerLockKey = 4804
+
+// ChangesPollInterval is how often WaitForChanges looks for new entries
+var ChangesPollInterval = 500 * time.Millisecond
+
+// SequencerConfig configures how committed entries are numbered for the changes feed
+type SequencerConfig struct {
+	// Interval is how often the sequencer looks for entries to number
+	Interval time.Duration
+	// BatchSize is the maximum number of entries numbered in a single DB transaction
+	BatchSize int
+}
+
+// DefaultSequencerConfig is used for every zero field of the SequencerConfig passed to NewSequencer
+var DefaultSequencerConfig = SequencerConfig{
+	Interval:  100 * time.Millisecond,
+	BatchSize: 1000,
+}
+
+// assignSequences numbers the settled entries whose transaction finished before every transaction
+// still running, in the order of their transaction IDs. An entry is only numbered once nothing
+// committed later can be numbered before it, so the sequence has neither gaps nor late arrivals.
+// The literal status condition lets the partial index of unsequenced entries be used. The numbering time
+// is taken once the lock is held, so it increases with the numbers across sequencers too.
+const assignSequences = `
+WITH next AS (
+	SELECT id, row_number() OVER (ORDER BY settled_xid, id) AS position
+	FROM transfers
+	WHERE sequence IS NULL AND status <> 'FAILED' AND status IN @settled
+		AND settled_xid < (pg_snapshot_xmin(pg_current_snapshot())::text)::bigint
+	ORDER BY settled_xid, id
+	LIMIT @limit
+)
+UPDATE transfers SET sequence = @last + next.position, sequenced_at = statement_timestamp()
+FROM next
+WHERE transfers.id = next.id`
+
+// AssignSequences numbers up to limit committed entries for the changes feed and returns their number.
+// It does nothing if another sequencer holds the lock.
+func AssignSequences(db *gorm.DB, limit int) (int, error) {
+	var assigned int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", sequencerLockKey).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to lock sequencer: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		var last int64
+		if err := tx.Model(&models.Transfer{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return fmt.Errorf("failed to load last sequence: %w", err)
+		}
+
+		res := tx.Exec(assignSequences, map[string]any{
+			"settled": models.SettledStatuses,
+			"limit":   limit,
+			"last":    last,
+		})
+		if res.Error != nil {
+			return fmt.Errorf("failed to assign sequences: %w", res.Error)
+		}
+		assigned = res.RowsAffected
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return int(assigned), nil
+}
+
+// sequencerMetrics are published on /debug/vars along with the other expvar variables
+var sequencerMetrics = expvar.NewMap("sequencer")
+
+// Sequencer numbers committed entries for the changes feed in the background. Any number of sequencers
+// may run in parallel, e.g. one per replica, only the one holding the lock assigns numbers.
+//
+// An entry is only numbered once every transaction that started before it committed has finished, so
+// a single long-running writing transaction anywhere in the database, e.g. a reporting job or the table
+// lock of RebuildProjections, stalls the whole feed until it ends. The sequencer publishes the entries
+// waiting for a number and the age of the oldest one as the sequencer metric, which tells a stalled
+// feed from an idle ledger.
+type Sequencer struct {
+	db     *gorm.DB
+	config SequencerConfig
+}
+
+func NewSequencer(db *gorm.DB, config SequencerConfig) *Sequencer {
+	if config.Interval <= 0 {
+		config.Interval = DefaultSequencerConfig.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultSequencerConfig.BatchSize
+	}
+	return &Sequencer{db: db, config: config}
+}
+
+// Start runs the sequencer until the context is cancelled
+func (s *Sequencer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			// Keep numbering while full batches are waiting
+			for {
+				assigned, err := AssignSequences(s.db.WithContext(ctx), s.config.BatchSize)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Failed to run sequencing: %v", err)
+				}
+				if err != nil || assigned < s.config.BatchSize {
+					break
+				}
+			}
+			if err := publishSequencerLag(s.db.WithContext(ctx)); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to measure the changes feed lag: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// publishSequencerLag publishes the number of settled entries waiting for a sequence number and how long
+// the oldest one has been waiting
+func publishSequencerLag(db *gorm.DB) error {
+	var lag struct {
+		Pending int64
+		Age     float64
+	}
+	err := db.Raw(`
+		SELECT COUNT(*) AS pending, COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(updated_at)), 0) AS age
+		FROM transfers
+		WHERE sequence IS NULL AND status <> 'FAILED' AND status IN @settled`,
+		sql.Named("settled", models.SettledStatuses),
+	).Scan(&lag).Error
+	if err != nil {
+		return err
+	}
+
+	setInt := func(key string, value int64) {
+		v := new(expvar.Int)
+		v.Set(value)
+		sequencerMetrics.Set(key, v)
+	}
+	setInt("pending_entries", lag.Pending)
+	setInt("oldest_pending_age_seconds", int64(lag.Age))
+	setInt("last_run_timestamp", time.Now().Unix())
+	return nil
+}
+
+// Changes returns up to limit settled entries after the given sequence number, in sequence order,
+// only those sent or received by the wallet if the address is set. Only entries already numbered by
+// a Sequencer are returned. Consumers resume from the sequence of the last entry they processed.
+func Changes(db *gorm.DB, address string, since int64, limit int) ([]models.Transfer, error) {
+	if since < 0 {
+		return nil, invalidArgument("since must not be negative")
+	}
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	query := db.Where("sequence > ?", since).Order("sequence").Limit(limit)
+	if address != "" {
+		query = query.Where("from_address = ? OR to_address = ?", address, address)
+	}
+
+	var transfers []models.Transfer
+	if err := query.Find(&transfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to load changes: %w", err)
+	}
+	return transfers, nil
+}
+
+// WaitForChanges is Changes waiting until there is at least one entry after the given sequence number.
+// It returns no entries and no error once the context is done.
+func WaitForChanges(ctx context.Context, db *gorm.DB, address string, since int64, limit int) ([]models.Transfer, error) {
+	ticker := time.NewTicker(ChangesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		transfers, err := Changes(db, address, since, limit)
+		if err != nil || len(transfers) > 0 {
+			return transfers, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
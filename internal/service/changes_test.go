@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestChanges_Resume(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := service.Transfer(testDB, "A", "B", 10)
+		require.NoError(t, err)
+	}
+	_, err = service.AssignSequences(testDB, 10)
+	require.NoError(t, err)
+
+	changes, err := service.Changes(testDB, "", 0, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, models.KindMint, changes[0].Kind)
+
+	// Resuming after the last processed entry neither skips nor repeats entries
+	rest, err := service.Changes(testDB, "", *changes[1].Sequence, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 2)
+
+	all := append(changes, rest...)
+	for i, change := range all {
+		require.Equal(t, int64(i+1), *change.Sequence)
+	}
+
+	more, err := service.Changes(testDB, "", *rest[1].Sequence, 10)
+	require.NoError(t, err)
+	require.Empty(t, more)
+}
+
+func TestChanges_WaitForSettledEntries(t *testing.T) {
+	testDB := dbtest.Setup(t)
+	sequencing, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	service.NewSequencer(testDB, service.SequencerConfig{Interval: 10 * time.Millisecond}).Start(sequencing)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	// Pending entries get their sequence number once they are completed
+	pending := &models.Transfer{Kind: models.KindTransfer, FromAddress: "A", ToAddress: "B", Amount: 5, Status: models.TransferPending}
+	require.NoError(t, testDB.Create(pending).Error)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	changes, err := service.WaitForChanges(ctx, testDB, "", 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = service.Transfer(testDB, "A", "B", 5)
+	require.NoError(t, err)
+
+	changes, err = service.WaitForChanges(context.Background(), testDB, "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.NotEqual(t, pending.ID, changes[0].ID)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// WalletBalance is the balance of a wallet at some point of the ledger
+type WalletBalance struct {
+	Address string `json:"address"`
+	Balance int    `json:"balance"`
+}
+
+// ledgerEntries lists the balance changes of the entries with sequence numbers in (@from, @to].
+// Only settled entries are numbered.
+const ledgerEntries = `
+	SELECT to_address AS address, amount FROM transfers
+	WHERE sequence > @from AND sequence <= @to AND to_address <> ''
+	UNION ALL
+	SELECT from_address, -amount FROM transfers
+	WHERE sequence > @from AND sequence <= @to AND from_address <> ''`
+
+// CheckpointBalances writes a round of checkpoints for every wallet with entries numbered since the previous
+// round, up to the last sequence number assigned. No entry is ever numbered below an assigned number, so
+// a round never misses an entry committed late. It returns the number of checkpoints written.
+func CheckpointBalances(db *gorm.DB) (int, error) {
+	var last, next int64
+	if err := db.Model(&models.BalanceCheckpoint{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+		return 0, fmt.Errorf("failed to load last checkpoint: %w", err)
+	}
+	if err := db.Model(&models.Transfer{}).Select("COALESCE(MAX(sequence), 0)").Scan(&next).Error; err != nil {
+		return 0, fmt.Errorf("failed to find checkpoint sequence: %w", err)
+	}
+	if next <= last {
+		return 0, nil
+	}
+
+	// Concurrent rounds compute the same rows for the same sequence, the first one wins
+	result := db.Exec(`
+		INSERT INTO balance_checkpoints (address, sequence, balance, created_at)
+		SELECT d.address, @to, COALESCE(c.balance, 0) + d.balance, NOW()
+		FROM (SELECT address, SUM(amount) AS balance FROM (`+ledgerEntries+`) e GROUP BY address) d
+		LEFT JOIN LATERAL (
+			SELECT balance FROM balance_checkpoints
+			WHERE address = d.address AND sequence <= @from
+			ORDER BY sequence DESC LIMIT 1
+		) c ON true
+		ON CONFLICT DO NOTHING`,
+		sql.Named("from", last),
+		sql.Named("to", next),
+	)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to write checkpoints: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// LedgerSequenceAt returns the sequence number of the last entry numbered at or before the given time, 0 if none.
+// Entries are numbered once they are committed, so an entry created before the time but settled after it,
+// like a queued transfer, is not included.
+func LedgerSequenceAt(db *gorm.DB, at time.Time) (int64, error) {
+	return lastSequence(db, "sequenced_at <= ?", at)
+}
+
+// lastSequence returns the sequence number of the last entry whose numbering time matches the condition, 0 if none.
+// Numbering times increase with the sequence numbers, so the entries matching are a prefix of the sequence.
+func lastSequence(db *gorm.DB, condition string, at time.Time) (int64, error) {
+	var sequence int64
+	err := db.Model(&models.Transfer{}).Select("sequence").Where(condition, at).
+		Order("sequenced_at DESC, sequence DESC").Limit(1).Scan(&sequence).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find ledger sequence: %w", err)
+	}
+	return sequence, nil
+}
+
+// BalanceAt returns the balance of the wallet, held balance included, after all entries up to the
+// sequence number. It replays the wallet's entries since its latest checkpoint before the sequence.
+func BalanceAt(db *gorm.DB, address string, sequence int64) (int, error) {
+	var checkpoint models.BalanceCheckpoint
+	err := db.Where("address = ? AND sequence <= ?", address, sequence).Order("sequence DESC").Take(&checkpoint).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	var delta int
+	err = db.Raw(`SELECT COALESCE(SUM(amount), 0) FROM (`+ledgerEntries+`) e WHERE address = @address`,
+		sql.Named("from", checkpoint.Sequence),
+		sql.Named("to", sequence),
+		sql.Named("address", address),
+	).Scan(&delta).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay ledger: %w", err)
+	}
+	return checkpoint.Balance + delta, nil
+}
+
+// Snapshot returns up to limit non-zero wallet balances after all entries up to the sequence number,
+// in address order starting after the given address. The balances of the latest checkpoint round
+// before the sequence are combined with the entries since that round.
+func Snapshot(db *gorm.DB, sequence int64, after string, limit int) ([]WalletBalance, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	var round int64
+	err := db.Model(&models.BalanceCheckpoint{}).Select("COALESCE(MAX(sequence), 0)").Where("sequence <= ?", sequence).Scan(&round).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	var balances []WalletBalance
+	err = db.Raw(`
+		WITH checkpoints AS (
+			SELECT DISTINCT ON (address) address, balance FROM balance_checkpoints
+			WHERE sequence <= @from AND address > @after
+			ORDER BY address, sequence DESC
+		), delta AS (
+			SELECT address, SUM(amount) AS balance FROM (`+ledgerEntries+`) e
+			WHERE address > @after
+			GROUP BY address
+		)
+		SELECT COALESCE(c.address, d.address) AS address, COALESCE(c.balance, 0) + COALESCE(d.balance, 0) AS balance
+		FROM checkpoints c FULL OUTER JOIN delta d ON c.address = d.address
+		WHERE COALESCE(c.balance, 0) + COALESCE(d.balance, 0) <> 0
+		ORDER BY 1
+		LIMIT @limit`,
+		sql.Named("from", round),
+		sql.Named("to", sequence),
+		sql.Named("after", after),
+		sql.Named("limit", limit),
+	).Scan(&balances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute snapshot: %w", err)
+	}
+	return balances, nil
+}
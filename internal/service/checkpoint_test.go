@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestBalanceAt(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	sequenceOf := func(entry *models.Transfer) int64 {
+		require.NoError(t, testDB.First(entry, entry.ID).Error)
+		return *entry.Sequence
+	}
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	first, _, err := service.TransferRecorded(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	_, err = service.AssignSequences(testDB, 10)
+	require.NoError(t, err)
+
+	written, err := service.CheckpointBalances(testDB)
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+
+	second, _, err := service.TransferRecorded(testDB, "A", "C", 20)
+	require.NoError(t, err)
+	_, _, err = service.TransferRecorded(testDB, "B", "C", 5)
+	require.NoError(t, err)
+
+	// Entries are part of the history once they are numbered, whenever they were created
+	at, err := service.LedgerSequenceAt(testDB, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, sequenceOf(first), at)
+
+	_, err = service.AssignSequences(testDB, 10)
+	require.NoError(t, err)
+
+	// Balances before and after the checkpoint combine it with the replayed entries
+	balance, err := service.BalanceAt(testDB, "A", sequenceOf(first))
+	require.NoError(t, err)
+	require.Equal(t, 70, balance)
+	balance, err = service.BalanceAt(testDB, "A", sequenceOf(second))
+	require.NoError(t, err)
+	require.Equal(t, 50, balance)
+
+	snapshot, err := service.Snapshot(testDB, sequenceOf(second), "", 10)
+	require.NoError(t, err)
+	require.Equal(t, []service.WalletBalance{{Address: "A", Balance: 50}, {Address: "B", Balance: 30}, {Address: "C", Balance: 20}}, snapshot)
+
+	// Nothing happened since the last round
+	_, err = service.CheckpointBalances(testDB)
+	require.NoError(t, err)
+	written, err = service.CheckpointBalances(testDB)
+	require.NoError(t, err)
+	require.Zero(t, written)
+
+	var checkpoint models.BalanceCheckpoint
+	require.NoError(t, testDB.Where("address = ?", "C").Order("sequence DESC").Take(&checkpoint).Error)
+	require.Equal(t, 25, checkpoint.Balance)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"token-transfer-api/internal/auth"
+)
+
+var (
+	// ErrInvalidArgument marks requests rejected before touching any wallet, e.g. a non-positive amount
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrWalletNotFound is returned when a wallet that must exist doesn't
+	ErrWalletNotFound = errors.New("wallet not found")
+
+	// ErrWalletFrozen is returned when a wallet frozen for sending or receiving would do so
+	ErrWalletFrozen = errors.New("wallet is frozen")
+
+	// ErrBlocked is returned when a blocklisted address would send or receive tokens
+	ErrBlocked = errors.New("address is blocklisted")
+
+	// ErrPaused is returned for any balance change while they are paused
+	ErrPaused = errors.New("transfers are paused")
+
+	// ErrInsufficientAllowance is returned when transferFrom exceeds what the owner allowed the spender to move
+	ErrInsufficientAllowance = errors.New("spender has insufficient allowance")
+
+	// ErrConflict is returned when the optimistic strategy ran out of retries
+	ErrConflict = errors.New("transfer conflicted with concurrent updates")
+)
+
+// Code classifies an error for the clients, every API maps it to its own status
+type Code string
+
+const (
+	CodeInvalidArgument     Code = "INVALID_ARGUMENT"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeWalletNotFound      Code = "WALLET_NOT_FOUND"
+	CodeWalletFrozen        Code = "WALLET_FROZEN"
+	CodeAddressBlocked      Code = "ADDRESS_BLOCKED"
+	CodePaused              Code = "PAUSED"
+	CodeConflict            Code = "CONFLICT"
+	CodeInsufficientBalance Code = "INSUFFICIENT_BALANCE"
+	CodeLimitExceeded       Code = "LIMIT_EXCEEDED"
+	// CodeInternal is any unexpected error, its message shouldn't be shown to the client
+	CodeInternal Code = "INTERNAL"
+)
+
+// Classify returns the code of an error returned by the service layer or the authorization checks
+func Classify(err error) Code {
+	var insufficient *InsufficientBalanceError
+	var limit *LimitExceededError
+
+	switch {
+	case errors.Is(err, ErrInvalidArgument):
+		return CodeInvalidArgument
+	case errors.Is(err, auth.ErrForbidden):
+		return CodeForbidden
+	case errors.Is(err, ErrWalletNotFound):
+		return CodeWalletNotFound
+	case errors.Is(err, ErrWalletFrozen):
+		return CodeWalletFrozen
+	case errors.Is(err, ErrBlocked):
+		return CodeAddressBlocked
+	case errors.Is(err, ErrPaused):
+		return CodePaused
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.As(err, &insufficient):
+		return CodeInsufficientBalance
+	case errors.As(err, &limit):
+		return CodeLimitExceeded
+	default:
+		return CodeInternal
+	}
+}
+
+// InsufficientBalanceError is returned when the sender holds fewer tokens than the transfer requires
+type InsufficientBalanceError struct {
+	Required  int
+	Available int
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("sender has insufficient balance: required %d, available %d", e.Required, e.Available)
+}
+
+// LimitExceededError is returned when a transfer would exceed a spending limit of the sender
+type LimitExceededError struct {
+	// Address is the wallet the limit was set for, empty for the global limit
+	Address string
+	// Limit describes the exceeded limit, e.g. "daily amount"
+	Limit string
+	// Remaining is what the sender may still send, or the number of transfers it may still make, under the limit
+	Remaining int
+}
+
+func (e *LimitExceededError) Error() string {
+	scope := "global"
+	if e.Address != "" {
+		scope = "wallet"
+	}
+	return fmt.Sprintf("%s %s limit exceeded: remaining %d", scope, e.Limit, e.Remaining)
+}
+
+// argumentError keeps the message of the validation error while matching ErrInvalidArgument
+type argumentError struct {
+	message string
+}
+
+func (e *argumentError) Error() string {
+	return e.message
+}
+
+func (e *argumentError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
+
+func invalidArgument(format string, args ...any) error {
+	return &argumentError{message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/auth"
+	"token-transfer-api/internal/service"
+)
+
+func TestClassify(t *testing.T) {
+	for err, code := range map[error]service.Code{
+		fmt.Errorf("%w: amount must be positive", service.ErrInvalidArgument): service.CodeInvalidArgument,
+		fmt.Errorf("%w: not authenticated", auth.ErrForbidden):                service.CodeForbidden,
+		fmt.Errorf("failed to transfer: %w", service.ErrWalletNotFound):       service.CodeWalletNotFound,
+		service.ErrWalletFrozen: service.CodeWalletFrozen,
+		service.ErrBlocked:      service.CodeAddressBlocked,
+		service.ErrPaused:       service.CodePaused,
+		service.ErrConflict:     service.CodeConflict,
+		&service.InsufficientBalanceError{Required: 10, Available: 5}:          service.CodeInsufficientBalance,
+		fmt.Errorf("wrapped: %w", &service.LimitExceededError{Limit: "daily"}): service.CodeLimitExceeded,
+		errors.New("connection refused"):                                       service.CodeInternal,
+	} {
+		require.Equal(t, code, service.Classify(err), err.Error())
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// feeScheduleID is the ID of the single fee schedule row
+const feeScheduleID = 1
+
+// FeeSchedule returns the current fee schedule, an empty one if fees were never configured
+func FeeSchedule(db *gorm.DB) (*models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+	err := db.First(&schedule, feeScheduleID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.FeeSchedule{ID: feeScheduleID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// SetFeeSchedule replaces the fee schedule, it applies to all transfers committed afterwards
+func SetFeeSchedule(db *gorm.DB, schedule models.FeeSchedule) (*models.FeeSchedule, error) {
+	if err := validateFeeSchedule(&schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = feeScheduleID
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&schedule).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// QuoteFee returns the fee a transfer of the amount from the sender would be charged right now
+func QuoteFee(db *gorm.DB, from string, amount int) (int, error) {
+	if amount <= 0 {
+		return 0, invalidArgument("transfer amount must be greater than 0")
+	}
+
+	schedule, err := FeeSchedule(db)
+	if err != nil {
+		return 0, err
+	}
+	return fee(schedule, from, amount), nil
+}
+
+// fee computes the fee of the transfer, the treasury doesn't pay fees to itself
+func fee(schedule *models.FeeSchedule, from string, amount int) int {
+	if schedule.Treasury == "" || from == schedule.Treasury {
+		return 0
+	}
+
+	flat, basisPoints := schedule.Flat, schedule.BasisPoints
+	for _, tier := range schedule.Tiers {
+		if amount < tier.MinAmount {
+			break
+		}
+		flat, basisPoints = tier.Flat, tier.BasisPoints
+	}
+
+	charged := flat + amount*basisPoints/10000
+	charged = max(charged, schedule.MinFee)
+	if schedule.MaxFee > 0 {
+		charged = min(charged, schedule.MaxFee)
+	}
+	return charged
+}
+
+// chargeFee moves the fee from the sender to the treasury after the transfer itself and returns
+// the new balance of the sender
+func chargeFee(tx *gorm.DB, from string, treasury string, amount int, charged int) (int, error) {
+	balance, err := debit(tx, from, charged)
+	if err != nil {
+		var insufficient *InsufficientBalanceError
+		if errors.As(err, &insufficient) {
+			// Report what the transfer required as a whole
+			return 0, &InsufficientBalanceError{Required: amount + charged, Available: insufficient.Available + amount}
+		}
+		return 0, err
+	}
+
+	if err := credit(tx, treasury, charged); err != nil {
+		return 0, fmt.Errorf("failed to credit fee: %w", err)
+	}
+	return balance, nil
+}
+
+// recordFee records the fee charged for the entry as a FEE ledger entry of its own pointing at it,
+// so the treasury's balance adds up to its entries
+func recordFee(tx *gorm.DB, parent *models.Transfer, treasury string) error {
+	entry := &models.Transfer{
+		Kind:        models.KindFee,
+		FromAddress: parent.FromAddress,
+		ToAddress:   treasury,
+		Amount:      parent.Fee,
+		Status:      models.TransferCompleted,
+		ParentID:    &parent.ID,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to record fee: %w", err)
+	}
+	return publishTransfer(tx, EventTransferCompleted, entry)
+}
+
+func validateFeeSchedule(schedule *models.FeeSchedule) error {
+	validRate := func(flat int, basisPoints int) bool {
+		return flat >= 0 && basisPoints >= 0 && basisPoints <= 10000
+	}
+
+	if !validRate(schedule.Flat, schedule.BasisPoints) {
+		return invalidArgument("flat fee must not be negative and basis points must be between 0 and 10000")
+	}
+	for i, tier := range schedule.Tiers {
+		if !validRate(tier.Flat, tier.BasisPoints) {
+			return invalidArgument("flat fee must not be negative and basis points must be between 0 and 10000")
+		}
+		if tier.MinAmount < 0 || (i > 0 && tier.MinAmount <= schedule.Tiers[i-1].MinAmount) {
+			return invalidArgument("tiers must be sorted by distinct, non-negative minimum amounts")
+		}
+	}
+	if schedule.MinFee < 0 || schedule.MaxFee < 0 || (schedule.MaxFee > 0 && schedule.MinFee > schedule.MaxFee) {
+		return invalidArgument("minimum and maximum fee must not be negative and the minimum must not exceed the maximum")
+	}
+	return nil
+}
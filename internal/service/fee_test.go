@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestTransfer_ChargesFee(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 2000}).Error)
+	require.NoError(t, testDB.Create(&models.Transfer{Kind: models.KindMint, ToAddress: "A", Amount: 2000, Status: models.TransferCompleted}).Error)
+
+	_, err := service.SetFeeSchedule(testDB, models.FeeSchedule{
+		Treasury:    "T",
+		Flat:        1,
+		BasisPoints: 100,
+		Tiers:       []models.FeeTier{{MinAmount: 1000, BasisPoints: 50}},
+		MinFee:      2,
+		MaxFee:      20,
+	})
+	require.NoError(t, err)
+
+	for amount, expected := range map[int]int{10: 2, 500: 6, 1000: 5, 100000: 20} {
+		quoted, err := service.QuoteFee(testDB, "A", amount)
+		require.NoError(t, err)
+		require.Equal(t, expected, quoted, "fee of %d", amount)
+	}
+
+	entry, balance, err := service.TransferRecorded(testDB, "A", "B", 500)
+	require.NoError(t, err)
+	require.Equal(t, 6, entry.Fee)
+	require.Equal(t, 1494, balance)
+
+	var feeEntry models.Transfer
+	require.NoError(t, testDB.First(&feeEntry, "parent_id = ?", entry.ID).Error)
+	require.Equal(t, models.KindFee, feeEntry.Kind)
+	require.Equal(t, "T", feeEntry.ToAddress)
+	require.Equal(t, 6, feeEntry.Amount)
+
+	treasury, err := service.Balance(testDB, "T")
+	require.NoError(t, err)
+	require.Equal(t, 6, treasury)
+
+	// The fee counts against the sender's balance
+	_, err = service.Transfer(testDB, "A", "B", 1494)
+	var insufficient *service.InsufficientBalanceError
+	require.ErrorAs(t, err, &insufficient)
+	require.Equal(t, 1494+7, insufficient.Required)
+	require.Equal(t, 1494, insufficient.Available)
+
+	report, err := service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%+v", report)
+}
+
+func TestSetFeeSchedule_Validation(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "T", BasisPoints: 10001})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	_, err = service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "T", MinFee: 10, MaxFee: 5})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	_, err = service.SetFeeSchedule(testDB, models.FeeSchedule{
+		Treasury: "T",
+		Tiers:    []models.FeeTier{{MinAmount: 100}, {MinAmount: 100}},
+	})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// FreezeWallet stops the wallet from sending, receiving or both, and records who did it and why
+func FreezeWallet(db *gorm.DB, address string, direction models.FreezeDirection, reason string, actor string) (*models.Wallet, error) {
+	return setFrozen(db, address, direction, true, reason, actor)
+}
+
+// UnfreezeWallet lets the wallet send, receive or both again, and records who did it and why
+func UnfreezeWallet(db *gorm.DB, address string, direction models.FreezeDirection, reason string, actor string) (*models.Wallet, error) {
+	return setFrozen(db, address, direction, false, reason, actor)
+}
+
+// AuditLog returns up to limit audit records, newest first, only those of the wallet if the address is set
+func AuditLog(db *gorm.DB, address string, limit int) ([]models.AuditRecord, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	query := db.Order("id DESC").Limit(limit)
+	if address != "" {
+		query = query.Where("address = ?", address)
+	}
+
+	var records []models.AuditRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load audit log: %w", err)
+	}
+	return records, nil
+}
+
+func setFrozen(db *gorm.DB, address string, direction models.FreezeDirection, frozen bool, reason string, actor string) (*models.Wallet, error) {
+	if direction != models.FreezeSend && direction != models.FreezeReceive && direction != models.FreezeBoth {
+		return nil, invalidArgument("unknown freeze direction %q", direction)
+	}
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, "address = ?", address).Error; err != nil {
+			return fmt.Errorf("%w: %w", ErrWalletNotFound, err)
+		}
+
+		if direction != models.FreezeReceive {
+			wallet.SendFrozen = frozen
+		}
+		if direction != models.FreezeSend {
+			wallet.ReceiveFrozen = frozen
+		}
+		if frozen {
+			wallet.FreezeReason = reason
+		} else if !wallet.SendFrozen && !wallet.ReceiveFrozen {
+			wallet.FreezeReason = ""
+		}
+
+		err := tx.Model(&wallet).Select("send_frozen", "receive_frozen", "freeze_reason").Updates(&wallet).Error
+		if err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+
+		action, eventType := models.AuditUnfreeze, models.EventUnfrozen
+		if frozen {
+			action, eventType = models.AuditFreeze, models.EventFrozen
+		}
+		err = appendEvent(tx, eventType, models.EventData{Address: address, Direction: direction, Reason: reason})
+		if err != nil {
+			return err
+		}
+		return audit(tx, models.AuditRecord{Action: action, Address: address, Direction: direction, Reason: reason, Actor: actor})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Wallet(db, address)
+}
+
+func audit(tx *gorm.DB, record models.AuditRecord) error {
+	if err := tx.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to record audit record: %w", err)
+	}
+	return nil
+}
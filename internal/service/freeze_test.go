@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestFreezeWallet(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 10}).Error)
+
+	wallet, err := service.FreezeWallet(testDB, "B", models.FreezeBoth, "court order", "admin")
+	require.NoError(t, err)
+	require.True(t, wallet.SendFrozen)
+	require.True(t, wallet.ReceiveFrozen)
+
+	// A frozen wallet can neither receive nor send tokens
+	_, err = service.Transfer(testDB, "A", "B", 1)
+	require.ErrorIs(t, err, service.ErrWalletFrozen)
+	_, err = service.Transfer(testDB, "B", "A", 1)
+	require.ErrorIs(t, err, service.ErrWalletFrozen)
+
+	_, err = service.UnfreezeWallet(testDB, "B", models.FreezeBoth, "order lifted", "admin")
+	require.NoError(t, err)
+
+	_, err = service.Transfer(testDB, "B", "A", 1)
+	require.NoError(t, err)
+
+	records, err := service.AuditLog(testDB, "B", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, models.AuditUnfreeze, records[0].Action)
+	require.Equal(t, "admin", records[1].Actor)
+}
+
+func TestFreezeWallet_Direction(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 10}).Error)
+
+	_, err := service.FreezeWallet(testDB, "B", models.FreezeSend, "investigation", "admin")
+	require.NoError(t, err)
+
+	// B can still receive tokens, but not send them
+	_, err = service.Transfer(testDB, "A", "B", 1)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "B", "A", 1)
+	require.ErrorIs(t, err, service.ErrWalletFrozen)
+}
+
+func TestPause(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	state, err := service.Pause(testDB, "incident", "admin")
+	require.NoError(t, err)
+	require.True(t, state.Paused)
+
+	_, err = service.Transfer(testDB, "A", "B", 1)
+	require.ErrorIs(t, err, service.ErrPaused)
+
+	_, err = service.Resume(testDB, "resolved", "admin")
+	require.NoError(t, err)
+
+	_, err = service.Transfer(testDB, "A", "B", 1)
+	require.NoError(t, err)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"token-transfer-api/internal/models"
+)
+
+// MaxPageSize is the maximum number of ledger entries returned by a single ListTransfers call
+const MaxPageSize = 500
+
+// ListTransfers returns a page of ledger entries, newest first. Only entries with an ID lower than
+// before are returned, 0 starts from the newest entry. An empty address lists entries of all wallets.
+func ListTransfers(db *gorm.DB, address string, before uint, limit int) ([]models.Transfer, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	query := db.Order("id DESC").Limit(limit)
+	if address != "" {
+		query = query.Where("from_address = ? OR to_address = ?", address, address)
+	}
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var transfers []models.Transfer
+	if err := query.Find(&transfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	return transfers, nil
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// ErrNotAuthorized is returned when capturing or voiding a hold that was already captured, voided or expired
+var ErrNotAuthorized = errors.New("hold is no longer authorized")
+
+// DefaultHoldTTL is how long holds authorized without an expiry time stay valid
+var DefaultHoldTTL = 7 * 24 * time.Hour
+
+// AuthorizeTransfer moves the amount from the sender's available balance to its held balance,
+// to be transferred to the receiver by CaptureTransfer. A zero expiresAt expires the hold after DefaultHoldTTL.
+func AuthorizeTransfer(db *gorm.DB, from string, to string, amount int, expiresAt time.Time) (*models.Hold, error) {
+	if err := validate(from, to, amount); err != nil {
+		return nil, err
+	}
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(DefaultHoldTTL)
+	} else if !expiresAt.After(time.Now()) {
+		return nil, invalidArgument("hold must expire in the future")
+	}
+
+	hold := &models.Hold{
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount,
+		ExpiresAt:   expiresAt,
+		Status:      models.HoldAuthorized,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// The receiver must be able to receive the tokens at capture time, but may not exist yet
+		if _, err := shardedWallets(tx, from, to); err != nil {
+			return err
+		}
+		if err := checkTransferable(tx, from, to); err != nil {
+			return err
+		}
+		if _, err := debit(tx, from, amount); err != nil {
+			return err
+		}
+		if err := addHeld(tx, from, amount, 0); err != nil {
+			return err
+		}
+		// The limits are enforced when the tokens are reserved, the capture can't exceed them anymore
+		if err := checkLimits(tx, from, amount); err != nil {
+			return err
+		}
+		if err := tx.Create(hold).Error; err != nil {
+			return fmt.Errorf("failed to record hold: %w", err)
+		}
+		return appendEvent(tx, models.EventHoldAuthorized, models.EventData{From: from, To: to, Amount: amount, HoldID: &hold.ID})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// CaptureTransfer transfers the amount of the hold to the receiver and releases the rest to the sender.
+// A zero amount captures the whole hold. The fee of the captured amount is charged like for a transfer,
+// from the sender's available balance once the rest is released.
+func CaptureTransfer(db *gorm.DB, id uint, amount int) (*models.Hold, error) {
+	var hold *models.Hold
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if hold, err = lockAuthorizedHold(tx, id); err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: expired at %s", ErrNotAuthorized, hold.ExpiresAt.Format(time.RFC3339))
+		}
+
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount < 0 || amount > hold.Amount {
+			return invalidArgument("capture amount must be between 1 and %d", hold.Amount)
+		}
+
+		schedule, err := FeeSchedule(tx)
+		if err != nil {
+			return err
+		}
+		charged := fee(schedule, hold.FromAddress, amount)
+
+		if _, err := shardedWallets(tx, hold.FromAddress, hold.ToAddress); err != nil {
+			return err
+		}
+		if err := checkTransferable(tx, hold.FromAddress, hold.ToAddress); err != nil {
+			return err
+		}
+		if charged > 0 {
+			if err := lockWallets(tx, hold.FromAddress, hold.ToAddress, schedule.Treasury); err != nil {
+				return err
+			}
+		}
+
+		// Update both wallets in alphabetical order of addresses to avoid deadlocks
+		release := func() error { return addHeld(tx, hold.FromAddress, -hold.Amount, hold.Amount-amount) }
+		receive := func() error { return credit(tx, hold.ToAddress, amount) }
+		steps := []func() error{release, receive}
+		if hold.ToAddress < hold.FromAddress {
+			steps[0], steps[1] = receive, release
+		}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+
+		// The captured amount was taken out of the available balance at authorization already
+		if charged > 0 {
+			if _, err := chargeFee(tx, hold.FromAddress, schedule.Treasury, 0, charged); err != nil {
+				return err
+			}
+		}
+
+		entry := &models.Transfer{Kind: models.KindTransfer, FromAddress: hold.FromAddress, ToAddress: hold.ToAddress, Amount: amount, Fee: charged}
+		if err := recordCompleted(tx, entry); err != nil {
+			return err
+		}
+		if charged > 0 {
+			if err := recordFee(tx, entry, schedule.Treasury); err != nil {
+				return err
+			}
+		}
+
+		hold.Status = models.HoldCaptured
+		hold.CapturedAmount = amount
+		hold.TransferID = &entry.ID
+		if err := tx.Save(hold).Error; err != nil {
+			return fmt.Errorf("failed to record hold: %w", err)
+		}
+		return appendEvent(tx, models.EventHoldCaptured, models.EventData{
+			From:       hold.FromAddress,
+			To:         hold.ToAddress,
+			Amount:     amount,
+			Released:   hold.Amount - amount,
+			HoldID:     &hold.ID,
+			TransferID: &entry.ID,
+			Fee:        charged,
+			Treasury:   schedule.Treasury,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// VoidTransfer releases the whole hold back to the sender's available balance
+func VoidTransfer(db *gorm.DB, id uint) (*models.Hold, error) {
+	var hold *models.Hold
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if hold, err = lockAuthorizedHold(tx, id); err != nil {
+			return err
+		}
+		return releaseHold(tx, hold, models.HoldVoided)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Hold loads the hold with the given ID
+func Hold(db *gorm.DB, id uint) (*models.Hold, error) {
+	var hold models.Hold
+	if err := db.First(&hold, id).Error; err != nil {
+		return nil, fmt.Errorf("hold not found: %w", err)
+	}
+	return &hold, nil
+}
+
+// ExpireHolds releases a batch of the holds expired at the given time and returns their number.
+// Holds locked by another scheduler, or being captured or voided, are skipped.
+func (s *Scheduler) ExpireHolds(now time.Time) (int, error) {
+	var expired []models.Hold
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", models.HoldAuthorized, now).
+			Order("expires_at, id").
+			Limit(s.config.BatchSize).
+			Find(&expired).Error
+		if err != nil {
+			return fmt.Errorf("failed to load expired holds: %w", err)
+		}
+
+		for i := range expired {
+			if err := releaseHold(tx, &expired[i], models.HoldExpired); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+func lockAuthorizedHold(tx *gorm.DB, id uint) (*models.Hold, error) {
+	var hold models.Hold
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, id).Error; err != nil {
+		return nil, fmt.Errorf("hold not found: %w", err)
+	}
+	if hold.Status != models.HoldAuthorized {
+		return nil, fmt.Errorf("%w: %s", ErrNotAuthorized, hold.Status)
+	}
+	return &hold, nil
+}
+
+// releaseHold moves the whole hold back to the sender's available balance and sets its final status
+func releaseHold(tx *gorm.DB, hold *models.Hold, status models.HoldStatus) error {
+	if err := addHeld(tx, hold.FromAddress, -hold.Amount, hold.Amount); err != nil {
+		return err
+	}
+
+	hold.Status = status
+	if err := tx.Save(hold).Error; err != nil {
+		return fmt.Errorf("failed to record hold: %w", err)
+	}
+	return appendEvent(tx, models.EventHoldReleased, models.EventData{From: hold.FromAddress, Amount: hold.Amount, HoldID: &hold.ID})
+}
+
+// addHeld changes the held balance of the wallet and adds the released amount to its available balance.
+// Unlike transfers, releasing tokens back to a frozen wallet is allowed.
+func addHeld(tx *gorm.DB, address string, held int, released int) error {
+	var wallet models.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).Select("address", "shards").First(&wallet, "address = ?", address).Error; err != nil {
+		return fmt.Errorf("%w: %w", ErrWalletNotFound, err)
+	}
+
+	updates := map[string]any{
+		"held":    gorm.Expr("held + ?", held),
+		"version": gorm.Expr("version + 1"),
+	}
+	if wallet.Shards == 0 {
+		updates["balance"] = gorm.Expr("balance + ?", released)
+	} else if released > 0 {
+		if err := creditShards(tx, address, wallet.Shards, released); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.Wallet{}).Where("address = ?", address).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update held balance: %w", err)
+	}
+	return nil
+}
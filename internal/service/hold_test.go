@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestHold_PartialCapture(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	hold, err := service.AuthorizeTransfer(testDB, "A", "B", 8, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, models.HoldAuthorized, hold.Status)
+
+	wallet, err := service.Wallet(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 2, wallet.Balance)
+	require.Equal(t, 8, wallet.Held)
+
+	// Held tokens can't be spent
+	_, err = service.Transfer(testDB, "A", "B", 3)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "insufficient balance")
+
+	hold, err = service.CaptureTransfer(testDB, hold.ID, 5)
+	require.NoError(t, err)
+	require.Equal(t, models.HoldCaptured, hold.Status)
+	require.Equal(t, 5, hold.CapturedAmount)
+	require.NotNil(t, hold.TransferID)
+
+	wallet, err = service.Wallet(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 5, wallet.Balance)
+	require.Zero(t, wallet.Held)
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 5, balance)
+
+	_, err = service.VoidTransfer(testDB, hold.ID)
+	require.ErrorIs(t, err, service.ErrNotAuthorized)
+
+	require.NoError(t, testDB.Create(&models.Transfer{Kind: models.KindMint, ToAddress: "A", Amount: 10, Status: models.TransferCompleted}).Error)
+	report, err := service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%+v", report)
+}
+
+func TestHold_VoidAndExpire(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	voided, err := service.AuthorizeTransfer(testDB, "A", "B", 4, time.Time{})
+	require.NoError(t, err)
+	expiring, err := service.AuthorizeTransfer(testDB, "A", "B", 6, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	_, err = service.AuthorizeTransfer(testDB, "A", "B", 1, time.Time{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "insufficient balance")
+
+	voided, err = service.VoidTransfer(testDB, voided.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.HoldVoided, voided.Status)
+
+	expired, err := service.NewScheduler(testDB, service.SchedulerConfig{}).ExpireHolds(time.Now().Add(2 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+
+	expiring, err = service.Hold(testDB, expiring.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.HoldExpired, expiring.Status)
+
+	wallet, err := service.Wallet(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 10, wallet.Balance)
+	require.Zero(t, wallet.Held)
+}
+
+func TestHold_CaptureChargesFee(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "T", Flat: 2})
+	require.NoError(t, err)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	hold, err := service.AuthorizeTransfer(testDB, "A", "B", 8, time.Time{})
+	require.NoError(t, err)
+
+	// The fee is paid out of the released rest of the hold
+	hold, err = service.CaptureTransfer(testDB, hold.ID, 5)
+	require.NoError(t, err)
+
+	var entry models.Transfer
+	require.NoError(t, testDB.First(&entry, *hold.TransferID).Error)
+	require.Equal(t, 2, entry.Fee)
+	var feeEntry models.Transfer
+	require.NoError(t, testDB.First(&feeEntry, "kind = ? AND parent_id = ?", models.KindFee, entry.ID).Error)
+	require.Equal(t, "T", feeEntry.ToAddress)
+
+	for address, expected := range map[string]int{"A": 3, "B": 5, "T": 2} {
+		balance, err := service.Balance(testDB, address)
+		require.NoError(t, err)
+		require.Equal(t, expected, balance, address)
+	}
+
+	// Without anything available to pay the fee, the hold can't be captured
+	hold, err = service.AuthorizeTransfer(testDB, "A", "B", 3, time.Time{})
+	require.NoError(t, err)
+	_, err = service.CaptureTransfer(testDB, hold.ID, 3)
+	var insufficient *service.InsufficientBalanceError
+	require.ErrorAs(t, err, &insufficient)
+	require.Equal(t, 2, insufficient.Required)
+
+	hold, err = service.Hold(testDB, hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.HoldAuthorized, hold.Status)
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// ErrAccountNotFound is returned for an account that isn't in the chart of accounts
+var ErrAccountNotFound = errors.New("account not found")
+
+// TrialBalanceReport lists the balances of the accounts from the journal entries created before At
+type TrialBalanceReport struct {
+	At time.Time `json:"at"`
+	// Debit and Credit are the totals over all accounts, they are equal when the journal balances
+	Debit  int `json:"debit"`
+	Credit int `json:"credit"`
+	// Lines are a page of the accounts with a non-zero balance, in code order
+	Lines []TrialBalanceLine `json:"lines"`
+}
+
+// TrialBalanceLine is the balance of an account on its debit or credit side
+type TrialBalanceLine struct {
+	Account models.Account `json:"account"`
+	Debit   int            `json:"debit"`
+	Credit  int            `json:"credit"`
+}
+
+// GeneralLedgerReport lists the postings of an account in entries created in [From, To)
+type GeneralLedgerReport struct {
+	Account models.Account `json:"account"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	// Opening is the balance before the first line, Closing the balance at To
+	Opening int          `json:"opening"`
+	Closing int          `json:"closing"`
+	Lines   []LedgerLine `json:"lines"`
+}
+
+// LedgerLine is a posting of the account, with the balance after it.
+// Balances are on the normal side of the account: debit for assets, credit otherwise.
+type LedgerLine struct {
+	PostingID   uint      `json:"postingId"`
+	EntryID     uint      `json:"entryId"`
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+	TransferID  *uint     `json:"transferId,omitempty"`
+	HoldID      *uint     `json:"holdId,omitempty"`
+	Debit       int       `json:"debit"`
+	Credit      int       `json:"credit"`
+	Balance     int       `json:"balance"`
+}
+
+// postEvent records the event in the journal. Events that move no tokens, like freezes, post nothing.
+// Only the fees are revenue, the tokens the fee treasury receives otherwise are owed to it like to any holder.
+func postEvent(tx *gorm.DB, event *models.Event) error {
+	var accounts []models.Account
+	wallet := func(address string) string {
+		account := models.WalletAccount(address)
+		accounts = append(accounts, account)
+		return account.Code
+	}
+
+	entry := &models.JournalEntry{EventID: &event.ID, TransferID: event.Data.TransferID, HoldID: event.Data.HoldID}
+	debit := func(account string, amount int) {
+		if amount != 0 {
+			entry.Postings = append(entry.Postings, models.JournalPosting{AccountCode: account, Amount: amount})
+		}
+	}
+
+	data := &event.Data
+	switch event.Type {
+	case models.EventMinted:
+		entry.Description = "Mint to " + data.To
+		debit(models.AccountIssuance, data.Amount)
+		debit(wallet(data.To), -data.Amount)
+	case models.EventBurned:
+		entry.Description = "Burn from " + data.From
+		debit(wallet(data.From), data.Amount)
+		debit(models.AccountIssuance, -data.Amount)
+	case models.EventTransferExecuted:
+		kind := string(data.Kind)
+		entry.Description = fmt.Sprintf("%s%s from %s to %s", kind[:1], strings.ToLower(kind[1:]), data.From, data.To)
+		debit(wallet(data.From), data.Amount)
+		debit(wallet(data.To), -data.Amount)
+		debit(wallet(data.From), data.Fee)
+		debit(models.AccountFees, -data.Fee)
+	case models.EventHoldAuthorized:
+		entry.Description = fmt.Sprintf("Hold %d from %s to %s", *data.HoldID, data.From, data.To)
+		debit(wallet(data.From), data.Amount)
+		debit(models.AccountSuspense, -data.Amount)
+	case models.EventHoldCaptured:
+		entry.Description = fmt.Sprintf("Capture of hold %d from %s to %s", *data.HoldID, data.From, data.To)
+		debit(models.AccountSuspense, data.Amount+data.Released)
+		debit(wallet(data.To), -data.Amount)
+		debit(wallet(data.From), -data.Released)
+		debit(wallet(data.From), data.Fee)
+		debit(models.AccountFees, -data.Fee)
+	case models.EventHoldReleased:
+		entry.Description = fmt.Sprintf("Release of hold %d to %s", *data.HoldID, data.From)
+		debit(models.AccountSuspense, data.Amount)
+		debit(wallet(data.From), -data.Amount)
+	}
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+	return post(tx, entry, accounts)
+}
+
+// post records the entry with its postings, adding the wallet accounts to the chart of accounts
+// when they are first used
+func post(tx *gorm.DB, entry *models.JournalEntry, accounts []models.Account) error {
+	sum := 0
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("journal entry %q doesn't balance: postings sum to %d", entry.Description, sum)
+	}
+
+	if len(accounts) > 0 {
+		// Sorted, so that transactions creating the same accounts don't deadlock
+		slices.SortFunc(accounts, func(a, b models.Account) int { return strings.Compare(a.Code, b.Code) })
+		accounts = slices.CompactFunc(accounts, func(a, b models.Account) bool { return a.Code == b.Code })
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&accounts).Error; err != nil {
+			return fmt.Errorf("failed to create accounts: %w", err)
+		}
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to post journal entry: %w", err)
+	}
+	return nil
+}
+
+// Accounts returns a page of the chart of accounts after the given code, in code order
+func Accounts(db *gorm.DB, after string, limit int) ([]models.Account, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	var accounts []models.Account
+	if err := db.Where("code > ?", after).Order("code").Limit(limit).Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// journalBalances sums the postings of the entries created before @at per account
+const journalBalances = `
+	SELECT p.account_code, SUM(p.amount) AS balance
+	FROM journal_postings p JOIN journal_entries e ON e.id = p.entry_id
+	WHERE e.created_at < @at
+	GROUP BY p.account_code`
+
+// TrialBalance returns the totals of the trial balance at the given time and a page of
+// its lines after the given account code, all from the same snapshot
+func TrialBalance(db *gorm.DB, at time.Time, after string, limit int) (*TrialBalanceReport, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	report := &TrialBalanceReport{At: at}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`
+			SELECT COALESCE(SUM(balance) FILTER (WHERE balance > 0), 0) AS debit,
+				COALESCE(-SUM(balance) FILTER (WHERE balance < 0), 0) AS credit
+			FROM (`+journalBalances+`) b`,
+			sql.Named("at", at),
+		).Row().Scan(&report.Debit, &report.Credit)
+		if err != nil {
+			return fmt.Errorf("failed to compute trial balance: %w", err)
+		}
+
+		var lines []struct {
+			models.Account
+			Balance int
+		}
+		err = tx.Raw(`
+			SELECT a.*, b.balance
+			FROM (`+journalBalances+`) b JOIN accounts a ON a.code = b.account_code
+			WHERE b.account_code > @after AND b.balance <> 0
+			ORDER BY a.code
+			LIMIT @limit`,
+			sql.Named("at", at),
+			sql.Named("after", after),
+			sql.Named("limit", limit),
+		).Scan(&lines).Error
+		if err != nil {
+			return fmt.Errorf("failed to compute trial balance: %w", err)
+		}
+
+		report.Lines = make([]TrialBalanceLine, len(lines))
+		for i, line := range lines {
+			report.Lines[i] = TrialBalanceLine{Account: line.Account, Debit: max(line.Balance, 0), Credit: max(-line.Balance, 0)}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GeneralLedger returns the opening and closing balances of the account for entries created
+// in [from, to) and a page of its postings after the given posting ID, all from the same snapshot
+func GeneralLedger(db *gorm.DB, code string, from time.Time, to time.Time, after uint, limit int) (*GeneralLedgerReport, error) {
+	if !from.Before(to) {
+		return nil, invalidArgument("ledger must start before it ends")
+	}
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	report := &GeneralLedgerReport{From: from, To: to}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&report.Account, "code = ?", code).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", ErrAccountNotFound, code)
+			}
+			return fmt.Errorf("failed to load account: %w", err)
+		}
+
+		// The lines of the previous pages are part of the opening balance of this one
+		var opening, closing int
+		err := tx.Raw(`
+			SELECT COALESCE(SUM(p.amount) FILTER (WHERE e.created_at < @from OR p.id <= @after), 0),
+				COALESCE(SUM(p.amount), 0)
+			FROM journal_postings p JOIN journal_entries e ON e.id = p.entry_id
+			WHERE p.account_code = @code AND e.created_at < @to`,
+			sql.Named("code", code),
+			sql.Named("from", from),
+			sql.Named("to", to),
+			sql.Named("after", after),
+		).Row().Scan(&opening, &closing)
+		if err != nil {
+			return fmt.Errorf("failed to compute ledger balances: %w", err)
+		}
+
+		var postings []struct {
+			models.JournalPosting
+			Time        time.Time
+			Description string
+			TransferID  *uint
+			HoldID      *uint
+		}
+		err = tx.Raw(`
+			SELECT p.*, e.created_at AS time, e.description, e.transfer_id, e.hold_id
+			FROM journal_postings p JOIN journal_entries e ON e.id = p.entry_id
+			WHERE p.account_code = @code AND e.created_at >= @from AND e.created_at < @to AND p.id > @after
+			ORDER BY p.id
+			LIMIT @limit`,
+			sql.Named("code", code),
+			sql.Named("from", from),
+			sql.Named("to", to),
+			sql.Named("after", after),
+			sql.Named("limit", limit),
+		).Scan(&postings).Error
+		if err != nil {
+			return fmt.Errorf("failed to load ledger postings: %w", err)
+		}
+
+		sign := normalSign(report.Account.Type)
+		report.Opening, report.Closing = sign*opening, sign*closing
+		balance := report.Opening
+		report.Lines = make([]LedgerLine, len(postings))
+		for i, p := range postings {
+			balance += sign * p.Amount
+			report.Lines[i] = LedgerLine{
+				PostingID:   p.ID,
+				EntryID:     p.EntryID,
+				Time:        p.Time,
+				Description: p.Description,
+				TransferID:  p.TransferID,
+				HoldID:      p.HoldID,
+				Debit:       max(p.Amount, 0),
+				Credit:      max(-p.Amount, 0),
+				Balance:     balance,
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// normalSign turns a debit balance into a balance on the normal side of the account type
+func normalSign(accountType models.AccountType) int {
+	if accountType == models.AccountAsset {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestJournal_TrialBalanceAndGeneralLedger(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "T", Flat: 1})
+	require.NoError(t, err)
+
+	_, err = service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	hold, err := service.AuthorizeTransfer(testDB, "A", "B", 20, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = service.CaptureTransfer(testDB, hold.ID, 15)
+	require.NoError(t, err)
+	_, err = service.Burn(testDB, "B", 5)
+	require.NoError(t, err)
+
+	// Every entry balances on its own
+	var unbalanced int64
+	err = testDB.Raw("SELECT COUNT(*) FROM (SELECT entry_id FROM journal_postings GROUP BY entry_id HAVING SUM(amount) <> 0) e").Scan(&unbalanced).Error
+	require.NoError(t, err)
+	require.Zero(t, unbalanced)
+
+	balances := func() map[string]int {
+		report, err := service.TrialBalance(testDB, time.Now(), "", service.MaxPageSize)
+		require.NoError(t, err)
+		require.Equal(t, report.Debit, report.Credit)
+
+		result := make(map[string]int)
+		for _, line := range report.Lines {
+			result[line.Account.Code] = line.Debit - line.Credit
+		}
+		return result
+	}
+	// The treasury's fees of the transfer and the capture are revenue and the hold left nothing in suspense
+	require.Equal(t, map[string]int{
+		models.AccountIssuance: 95,
+		models.AccountFees:     -2,
+		"wallet:A":             -53,
+		"wallet:B":             -40,
+	}, balances())
+
+	ledger, err := service.GeneralLedger(testDB, "wallet:A", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, models.AccountLiability, ledger.Account.Type)
+	require.Zero(t, ledger.Opening)
+	require.Equal(t, 53, ledger.Closing)
+	require.Len(t, ledger.Lines, 3)
+	require.Equal(t, 100, ledger.Lines[0].Credit)
+	require.Equal(t, 30, ledger.Lines[1].Debit)
+	require.Equal(t, 69, ledger.Lines[2].Balance)
+
+	// The next page opens with the balance the previous one ended with
+	next, err := service.GeneralLedger(testDB, "wallet:A", ledger.From, ledger.To, ledger.Lines[2].PostingID, 3)
+	require.NoError(t, err)
+	require.Equal(t, 69, next.Opening)
+	require.Len(t, next.Lines, 3)
+	require.Equal(t, 49, next.Lines[0].Balance)
+	require.Equal(t, 54, next.Lines[1].Balance)
+	require.Equal(t, 53, next.Lines[2].Balance)
+
+	// Only the fee is revenue, the tokens sent to the treasury are owed to it like to any holder
+	_, err = service.Transfer(testDB, "A", "T", 10)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		models.AccountIssuance: 95,
+		models.AccountFees:     -3,
+		"wallet:A":             -42,
+		"wallet:B":             -40,
+		"wallet:T":             -10,
+	}, balances())
+
+	// Moving the treasury leaves the revenue and the wallets' balances as they are
+	_, err = service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "B", Flat: 1})
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "T", "A", 5)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		models.AccountIssuance: 95,
+		models.AccountFees:     -4,
+		"wallet:A":             -47,
+		"wallet:B":             -40,
+		"wallet:T":             -4,
+	}, balances())
+
+	_, err = service.GeneralLedger(testDB, "wallet:unknown", ledger.From, ledger.To, 0, 10)
+	require.ErrorIs(t, err, service.ErrAccountNotFound)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// SetSpendingLimit replaces the limits of the wallet, or the global limits if the address is empty.
+// The global limits apply to each wallet on its own, on top of its own limits, not to all wallets together.
+func SetSpendingLimit(db *gorm.DB, limit models.SpendingLimit) (*models.SpendingLimit, error) {
+	if limit.PerTransfer < 0 || limit.Daily < 0 || limit.Monthly < 0 || limit.PerMinute < 0 {
+		return nil, invalidArgument("limits must not be negative")
+	}
+
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&limit).Error; err != nil {
+		return nil, fmt.Errorf("failed to update spending limit: %w", err)
+	}
+	return &limit, nil
+}
+
+// SpendingLimit returns the limits of the wallet, or the global limits if the address is empty.
+// Wallets without limits get an empty one.
+func SpendingLimit(db *gorm.DB, address string) (*models.SpendingLimit, error) {
+	var limit models.SpendingLimit
+	err := db.First(&limit, "address = ?", address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.SpendingLimit{Address: address}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spending limit: %w", err)
+	}
+	return &limit, nil
+}
+
+// spent is what the wallet sent recently, according to the ledger and the holds not captured yet
+type spent struct {
+	Daily     int
+	Monthly   int
+	PerMinute int
+}
+
+// checkLimits fails if the transfer or hold would exceed the limits of the sender or the global limits.
+// Authorized holds count as sent until they are captured, then their ledger entry counts instead.
+func checkLimits(tx *gorm.DB, from string, amount int) error {
+	var limits []models.SpendingLimit
+	if err := tx.Where("address IN ?", []string{from, ""}).Find(&limits).Error; err != nil {
+		return fmt.Errorf("failed to load spending limits: %w", err)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	var sent *spent
+	for _, limit := range limits {
+		if limit.PerTransfer > 0 && amount > limit.PerTransfer {
+			return &LimitExceededError{Address: limit.Address, Limit: "amount per transfer", Remaining: limit.PerTransfer}
+		}
+		if limit.Daily == 0 && limit.Monthly == 0 && limit.PerMinute == 0 {
+			continue
+		}
+
+		if sent == nil {
+			// Concurrent checks of the sender wait for each other, so each one sums what the others sent.
+			// Unsharded senders are already locked by their debit, sharded ones only hold a key share lock.
+			err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+				Select("address").Where("address = ?", from).Find(&[]models.Wallet{}).Error
+			if err != nil {
+				return fmt.Errorf("failed to lock sender: %w", err)
+			}
+
+			now := time.Now()
+			sent = &spent{}
+			err = tx.Raw(`
+				SELECT
+					COALESCE(SUM(amount) FILTER (WHERE created_at > @day), 0) AS daily,
+					COALESCE(SUM(amount), 0) AS monthly,
+					COUNT(*) FILTER (WHERE created_at > @minute) AS per_minute
+				FROM (
+					SELECT amount, created_at FROM transfers
+					WHERE from_address = @from AND kind = @kind AND status IN @settled AND created_at > @month
+					UNION ALL
+					SELECT amount, created_at FROM holds
+					WHERE from_address = @from AND status = @authorized AND created_at > @month
+				) sent`,
+				sql.Named("from", from),
+				sql.Named("kind", models.KindTransfer),
+				sql.Named("settled", models.SettledStatuses),
+				sql.Named("authorized", models.HoldAuthorized),
+				sql.Named("minute", now.Add(-time.Minute)),
+				sql.Named("day", now.Add(-24*time.Hour)),
+				sql.Named("month", now.Add(-30*24*time.Hour)),
+			).Scan(sent).Error
+			if err != nil {
+				return fmt.Errorf("failed to sum sent amounts: %w", err)
+			}
+		}
+
+		if limit.Daily > 0 && sent.Daily+amount > limit.Daily {
+			return &LimitExceededError{Address: limit.Address, Limit: "daily amount", Remaining: max(limit.Daily-sent.Daily, 0)}
+		}
+		if limit.Monthly > 0 && sent.Monthly+amount > limit.Monthly {
+			return &LimitExceededError{Address: limit.Address, Limit: "monthly amount", Remaining: max(limit.Monthly-sent.Monthly, 0)}
+		}
+		if limit.PerMinute > 0 && sent.PerMinute+1 > limit.PerMinute {
+			return &LimitExceededError{Address: limit.Address, Limit: "transfers per minute", Remaining: 0}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestTransfer_SpendingLimits(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+
+	_, err := service.SetSpendingLimit(testDB, models.SpendingLimit{Address: "A", PerTransfer: 100, Daily: 150})
+	require.NoError(t, err)
+	_, err = service.SetSpendingLimit(testDB, models.SpendingLimit{PerMinute: 3})
+	require.NoError(t, err)
+
+	var exceeded *service.LimitExceededError
+
+	_, err = service.Transfer(testDB, "A", "B", 101)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "amount per transfer", exceeded.Limit)
+
+	_, err = service.Transfer(testDB, "A", "B", 100)
+	require.NoError(t, err)
+
+	_, err = service.Transfer(testDB, "A", "B", 60)
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "A", exceeded.Address)
+	require.Equal(t, "daily amount", exceeded.Limit)
+	require.Equal(t, 50, exceeded.Remaining)
+
+	// Failed transfers don't count
+	_, err = service.Transfer(testDB, "A", "B", 10)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 10)
+	require.NoError(t, err)
+
+	_, err = service.Transfer(testDB, "A", "B", 10)
+	require.ErrorAs(t, err, &exceeded)
+	require.Empty(t, exceeded.Address)
+	require.Equal(t, "transfers per minute", exceeded.Limit)
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 880, balance)
+}
+
+func TestTransfer_ConcurrentShardedSenderLimits(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+	_, err := service.EnableSharding(testDB, "A", 8)
+	require.NoError(t, err)
+	_, err = service.SetSpendingLimit(testDB, models.SpendingLimit{Address: "A", Daily: 50})
+	require.NoError(t, err)
+
+	// The transfers from different slots don't wait for each other, but their limit checks do
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Transfer(testDB, "A", "B", 1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		var exceeded *service.LimitExceededError
+		if err == nil {
+			succeeded++
+		} else {
+			require.ErrorAs(t, err, &exceeded)
+		}
+	}
+	require.Equal(t, 50, succeeded)
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 950, balance)
+}
+
+func TestAuthorizeTransfer_SpendingLimits(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+
+	_, err := service.SetSpendingLimit(testDB, models.SpendingLimit{Address: "A", PerTransfer: 100, Daily: 150})
+	require.NoError(t, err)
+
+	var exceeded *service.LimitExceededError
+
+	_, err = service.AuthorizeTransfer(testDB, "A", "B", 101, time.Time{})
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "amount per transfer", exceeded.Limit)
+
+	hold, err := service.AuthorizeTransfer(testDB, "A", "B", 100, time.Time{})
+	require.NoError(t, err)
+
+	// The hold counts against the daily limit before it is captured
+	_, err = service.AuthorizeTransfer(testDB, "A", "B", 60, time.Time{})
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, "daily amount", exceeded.Limit)
+	require.Equal(t, 50, exceeded.Remaining)
+	_, err = service.Transfer(testDB, "A", "B", 60)
+	require.ErrorAs(t, err, &exceeded)
+
+	// Once captured, only the captured amount counts
+	_, err = service.CaptureTransfer(testDB, hold.ID, 40)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 100)
+	require.NoError(t, err)
+
+	wallet, err := service.Wallet(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 860, wallet.Balance)
+	require.Zero(t, wallet.Held)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// Event types written to the outbox
+const (
+	// EventTransferCompleted is published for every ledger entry whose tokens moved, whatever its kind
+	EventTransferCompleted = "transfer.completed"
+	// EventTransferFailed is published for asynchronous transfers and standing order runs that failed
+	EventTransferFailed = "transfer.failed"
+)
+
+// EventTypes are all event types a webhook can subscribe to
+var EventTypes = []string{EventTransferCompleted, EventTransferFailed}
+
+// TransferEvent is the payload of the transfer events
+type TransferEvent struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Amount    int       `json:"amount"`
+	Fee       int       `json:"fee"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ParentID  string    `json:"parentId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// publishTransfer writes the event of the ledger entry to the outbox, as part of the transaction recording it
+func publishTransfer(tx *gorm.DB, eventType string, transfer *models.Transfer) error {
+	data := TransferEvent{
+		ID:        strconv.FormatUint(uint64(transfer.ID), 10),
+		Kind:      string(transfer.Kind),
+		From:      transfer.FromAddress,
+		To:        transfer.ToAddress,
+		Amount:    transfer.Amount,
+		Fee:       transfer.Fee,
+		Status:    string(transfer.Status),
+		Reason:    transfer.Reason,
+		CreatedAt: transfer.CreatedAt,
+	}
+	if transfer.ParentID != nil {
+		data.ParentID = strconv.FormatUint(uint64(*transfer.ParentID), 10)
+	}
+	return publish(tx, eventType, data)
+}
+
+func publish(tx *gorm.DB, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	if err := tx.Create(&models.OutboxEvent{Type: eventType, Payload: payload}).Error; err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// pauseStateID is the ID of the single pause state row
+const pauseStateID = 1
+
+// Pause halts all balance changes, e.g. during an incident, until Resume is called
+func Pause(db *gorm.DB, reason string, actor string) (*models.PauseState, error) {
+	return setPaused(db, true, reason, actor)
+}
+
+// Resume lets balance changes happen again after Pause
+func Resume(db *gorm.DB, reason string, actor string) (*models.PauseState, error) {
+	return setPaused(db, false, reason, actor)
+}
+
+// PauseState returns whether balance changes are paused
+func PauseState(db *gorm.DB) (*models.PauseState, error) {
+	var state models.PauseState
+	err := db.First(&state, pauseStateID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.PauseState{ID: pauseStateID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pause state: %w", err)
+	}
+	return &state, nil
+}
+
+func setPaused(db *gorm.DB, paused bool, reason string, actor string) (*models.PauseState, error) {
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	state := &models.PauseState{ID: pauseStateID, Paused: paused, Reason: reason, Actor: actor}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(state).Error; err != nil {
+			return fmt.Errorf("failed to update pause state: %w", err)
+		}
+
+		action := models.AuditResume
+		if paused {
+			action = models.AuditPause
+		}
+		return audit(tx, models.AuditRecord{Action: action, Reason: reason, Actor: actor})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// checkPaused fails with ErrPaused while balance changes are paused. The state is read FOR SHARE,
+// so Pause waits for the balance changes in flight and those started afterwards see it.
+func checkPaused(tx *gorm.DB) error {
+	state, err := PauseState(tx.Clauses(clause.Locking{Strength: "SHARE"}))
+	if err != nil {
+		return err
+	}
+	if state.Paused {
+		return fmt.Errorf("%w: %s", ErrPaused, state.Reason)
+	}
+	return nil
+}
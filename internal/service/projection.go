@@ -0,0 +1,267 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
+	"token-transfer-api/internal/models"
+)
+
+// eventBatchSize is the number of events loaded at once while replaying the event store
+const eventBatchSize = 1000
+
+// WalletProjection is the state of a wallet derived from the events
+type WalletProjection struct {
+	Address string `json:"address"`
+	// Balance is the available balance, including all slots of a sharded wallet
+	Balance       int    `json:"balance"`
+	Held          int    `json:"held"`
+	SendFrozen    bool   `json:"sendFrozen"`
+	ReceiveFrozen bool   `json:"receiveFrozen"`
+	FreezeReason  string `json:"freezeReason,omitempty"`
+}
+
+// ProjectionMismatch is a wallet whose live state differs from the state rebuilt from the events
+type ProjectionMismatch struct {
+	Live    WalletProjection `json:"live"`
+	Rebuilt WalletProjection `json:"rebuilt"`
+}
+
+// ProjectionReport is the result of replaying the event store
+type ProjectionReport struct {
+	Events  int `json:"events"`
+	Wallets int `json:"wallets"`
+	// Mismatches are the wallets whose live state differed from the rebuilt one, in address order
+	Mismatches []ProjectionMismatch `json:"mismatches"`
+}
+
+// OK reports whether the live tables match the events
+func (r *ProjectionReport) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// appendEvent records the change in the event store and posts it to the journal, in the same
+// transaction as the wallets it changes
+func appendEvent(tx *gorm.DB, eventType models.EventType, data models.EventData) error {
+	event := &models.Event{Type: eventType, Data: data}
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to append %s event: %w", eventType, err)
+	}
+	return postEvent(tx, event)
+}
+
+// VerifyProjections rebuilds the wallets from the events and compares them with the live tables,
+// all from the same snapshot, without changing anything
+func VerifyProjections(db *gorm.DB) (*ProjectionReport, error) {
+	var report *ProjectionReport
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		report, _, err = compareProjections(tx)
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// RebuildProjections rebuilds the wallets from the events and overwrites the live state of the wallets
+// that differ. Balance changes wait until it is done. The report lists the wallets it changed.
+func RebuildProjections(db *gorm.DB) (*ProjectionReport, error) {
+	var report *ProjectionReport
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Writers update the wallets before appending their events, so once the lock is granted, the
+		// snapshot taken by the first query holds every event of the committed balance changes
+		if err := tx.Exec("LOCK TABLE wallets, wallet_shards IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return fmt.Errorf("failed to lock wallets: %w", err)
+		}
+
+		var shards map[string]int
+		var err error
+		if report, shards, err = compareProjections(tx); err != nil {
+			return err
+		}
+
+		for _, mismatch := range report.Mismatches {
+			if err := applyProjection(tx, &mismatch.Rebuilt, shards[mismatch.Rebuilt.Address]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// compareProjections replays all events and compares the result with the live wallets.
+// It also returns the number of slots of the sharded wallets.
+func compareProjections(tx *gorm.DB) (*ProjectionReport, map[string]int, error) {
+	report := &ProjectionReport{}
+	rebuilt := projector{}
+
+	var batch []models.Event
+	err := tx.FindInBatches(&batch, eventBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range batch {
+			rebuilt.apply(&batch[i])
+		}
+		report.Events += len(batch)
+		return nil
+	}).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to replay events: %w", err)
+	}
+
+	var live []struct {
+		WalletProjection
+		Shards int
+	}
+	err = tx.Raw(`
+		SELECT w.address, w.balance + COALESCE(SUM(s.balance), 0) AS balance, w.held, w.send_frozen,
+			w.receive_frozen, w.freeze_reason, w.shards
+		FROM wallets w LEFT JOIN wallet_shards s ON s.address = w.address
+		GROUP BY w.address`,
+	).Scan(&live).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load wallets: %w", err)
+	}
+
+	shards := make(map[string]int)
+	for _, wallet := range live {
+		if wallet.Shards > 0 {
+			shards[wallet.Address] = wallet.Shards
+		}
+		if wallet.WalletProjection != *rebuilt.wallet(wallet.Address) {
+			report.Mismatches = append(report.Mismatches, ProjectionMismatch{Live: wallet.WalletProjection, Rebuilt: *rebuilt[wallet.Address]})
+		}
+		delete(rebuilt, wallet.Address)
+	}
+	report.Wallets = len(live)
+
+	// Wallets only known to the events are missing from the live tables
+	for address, wallet := range rebuilt {
+		if *wallet != (WalletProjection{Address: address}) {
+			report.Mismatches = append(report.Mismatches, ProjectionMismatch{Live: WalletProjection{Address: address}, Rebuilt: *wallet})
+		}
+	}
+	slices.SortFunc(report.Mismatches, func(a, b ProjectionMismatch) int {
+		return strings.Compare(a.Rebuilt.Address, b.Rebuilt.Address)
+	})
+	return report, shards, nil
+}
+
+// applyProjection overwrites the live state of the wallet, spreading the balance of a sharded wallet across its slots
+func applyProjection(tx *gorm.DB, projection *WalletProjection, slots int) error {
+	wallet := models.Wallet{
+		Address:       projection.Address,
+		Balance:       projection.Balance,
+		Held:          projection.Held,
+		Shards:        slots,
+		SendFrozen:    projection.SendFrozen,
+		ReceiveFrozen: projection.ReceiveFrozen,
+		FreezeReason:  projection.FreezeReason,
+	}
+
+	if slots > 0 {
+		if err := tx.Where("address = ?", wallet.Address).Delete(&models.WalletShard{}).Error; err != nil {
+			return fmt.Errorf("failed to delete wallet slots: %w", err)
+		}
+		if err := tx.Create(spread(wallet.Address, wallet.Balance, slots)).Error; err != nil {
+			return fmt.Errorf("failed to create wallet slots: %w", err)
+		}
+		wallet.Balance = 0
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "address"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"balance":        wallet.Balance,
+			"held":           wallet.Held,
+			"send_frozen":    wallet.SendFrozen,
+			"receive_frozen": wallet.ReceiveFrozen,
+			"freeze_reason":  wallet.FreezeReason,
+			"version":        gorm.Expr("wallets.version + 1"),
+		}),
+	}).Create(&wallet).Error
+	if err != nil {
+		return fmt.Errorf("failed to rebuild wallet %s: %w", wallet.Address, err)
+	}
+	return nil
+}
+
+// projector folds events into the state of the wallets
+type projector map[string]*WalletProjection
+
+func (p projector) wallet(address string) *WalletProjection {
+	wallet, ok := p[address]
+	if !ok {
+		wallet = &WalletProjection{Address: address}
+		p[address] = wallet
+	}
+	return wallet
+}
+
+func (p projector) apply(event *models.Event) {
+	data := &event.Data
+
+	switch event.Type {
+	case models.EventWalletImported:
+		*p.wallet(data.Address) = WalletProjection{
+			Address:       data.Address,
+			Balance:       data.Balance,
+			Held:          data.Held,
+			SendFrozen:    data.SendFrozen,
+			ReceiveFrozen: data.ReceiveFrozen,
+			FreezeReason:  data.Reason,
+		}
+	case models.EventMinted:
+		p.wallet(data.To).Balance += data.Amount
+	case models.EventBurned:
+		p.wallet(data.From).Balance -= data.Amount
+	case models.EventTransferExecuted:
+		p.wallet(data.From).Balance -= data.Amount + data.Fee
+		p.wallet(data.To).Balance += data.Amount
+		if data.Fee > 0 {
+			p.wallet(data.Treasury).Balance += data.Fee
+		}
+	case models.EventHoldAuthorized:
+		sender := p.wallet(data.From)
+		sender.Balance -= data.Amount
+		sender.Held += data.Amount
+	case models.EventHoldCaptured:
+		sender := p.wallet(data.From)
+		sender.Held -= data.Amount + data.Released
+		sender.Balance += data.Released - data.Fee
+		p.wallet(data.To).Balance += data.Amount
+		if data.Fee > 0 {
+			p.wallet(data.Treasury).Balance += data.Fee
+		}
+	case models.EventHoldReleased:
+		sender := p.wallet(data.From)
+		sender.Held -= data.Amount
+		sender.Balance += data.Amount
+	case models.EventFrozen, models.EventUnfrozen:
+		// The same rules as setFrozen
+		wallet := p.wallet(data.Address)
+		frozen := event.Type == models.EventFrozen
+		if data.Direction != models.FreezeReceive {
+			wallet.SendFrozen = frozen
+		}
+		if data.Direction != models.FreezeSend {
+			wallet.ReceiveFrozen = frozen
+		}
+		if frozen {
+			wallet.FreezeReason = data.Reason
+		} else if !wallet.SendFrozen && !wallet.ReceiveFrozen {
+			wallet.FreezeReason = ""
+		}
+	}
+}
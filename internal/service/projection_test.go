@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestProjections_Rebuild(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	_, err = service.Refund(testDB, entry.ID, 10)
+	require.NoError(t, err)
+
+	hold, err := service.AuthorizeTransfer(testDB, "A", "C", 20, time.Time{})
+	require.NoError(t, err)
+	_, err = service.CaptureTransfer(testDB, hold.ID, 15)
+	require.NoError(t, err)
+	_, err = service.AuthorizeTransfer(testDB, "A", "C", 5, time.Time{})
+	require.NoError(t, err)
+
+	_, err = service.FreezeWallet(testDB, "B", models.FreezeSend, "investigation", "admin")
+	require.NoError(t, err)
+	_, err = service.EnableSharding(testDB, "C", 4)
+	require.NoError(t, err)
+
+	report, err := service.VerifyProjections(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "mismatches: %+v", report.Mismatches)
+	require.Equal(t, 3, report.Wallets)
+
+	// Changes bypassing the event store are found and undone
+	require.NoError(t, testDB.Model(&models.Wallet{}).Where("address = ?", "A").Update("balance", 1000).Error)
+	require.NoError(t, testDB.Model(&models.WalletShard{}).Where("address = ? AND slot = 0", "C").Update("balance", 0).Error)
+
+	report, err = service.VerifyProjections(testDB)
+	require.NoError(t, err)
+	require.Len(t, report.Mismatches, 2)
+	require.Equal(t, "A", report.Mismatches[0].Live.Address)
+	require.Equal(t, 1000, report.Mismatches[0].Live.Balance)
+	require.Equal(t, 60, report.Mismatches[0].Rebuilt.Balance)
+	require.Equal(t, 5, report.Mismatches[0].Rebuilt.Held)
+
+	_, err = service.RebuildProjections(testDB)
+	require.NoError(t, err)
+
+	report, err = service.VerifyProjections(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "mismatches: %+v", report.Mismatches)
+
+	balance, err := service.Balance(testDB, "C")
+	require.NoError(t, err)
+	require.Equal(t, 15, balance)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sync"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// QueueConfig configures the worker pool of the asynchronous transfer queue
+type QueueConfig struct {
+	// Workers is the number of goroutines committing batches in parallel
+	Workers int
+	// BatchSize is the maximum number of transfers applied in a single DB transaction
+	BatchSize int
+	// BatchWait is how long a worker waits for more transfers before committing a partial batch
+	BatchWait time.Duration
+	// SweepInterval is how often transfers left pending (e.g. by a crashed replica) are picked up again
+	SweepInterval time.Duration
+	// PollInterval is how often subscriptions check for transfers finished by another replica
+	PollInterval time.Duration
+}
+
+// DefaultQueueConfig is used for every zero field of the QueueConfig passed to NewQueue
+var DefaultQueueConfig = QueueConfig{
+	Workers:       4,
+	BatchSize:     100,
+	BatchWait:     5 * time.Millisecond,
+	SweepInterval: 30 * time.Second,
+	PollInterval:  time.Second,
+}
+
+// Queue applies submitted transfers asynchronously, many transfers per DB transaction (group commit)
+type Queue struct {
+	db     *gorm.DB
+	config QueueConfig
+	jobs   chan uint
+
+	mu          sync.Mutex
+	subscribers map[uint][]chan models.Transfer
+}
+
+func NewQueue(db *gorm.DB, config QueueConfig) *Queue {
+	if config.Workers <= 0 {
+		config.Workers = DefaultQueueConfig.Workers
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultQueueConfig.BatchSize
+	}
+	if config.BatchWait <= 0 {
+		config.BatchWait = DefaultQueueConfig.BatchWait
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = DefaultQueueConfig.SweepInterval
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultQueueConfig.PollInterval
+	}
+
+	return &Queue{
+		db:          db,
+		config:      config,
+		jobs:        make(chan uint, config.Workers*config.BatchSize*4),
+		subscribers: make(map[uint][]chan models.Transfer),
+	}
+}
+
+// Start runs the workers, the sweeper of pending transfers and the poller of subscribed transfers
+// until the context is cancelled
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.config.Workers; i++ {
+		go q.work(ctx)
+	}
+	go q.sweep(ctx)
+	go q.poll(ctx)
+}
+
+// Submit records the transfer as pending and enqueues it, the transfer is applied later by a worker
+func (q *Queue) Submit(ctx context.Context, from string, to string, amount int) (*models.Transfer, error) {
+	if err := validate(from, to, amount); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.Transfer{
+		Kind:        models.KindTransfer,
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount,
+		Status:      models.TransferPending,
+	}
+	if err := q.db.WithContext(ctx).Create(transfer).Error; err != nil {
+		return nil, fmt.Errorf("failed to record transfer: %w", err)
+	}
+
+	// If the queue is full the transfer stays pending and is picked up by the sweeper
+	select {
+	case q.jobs <- transfer.ID:
+	default:
+	}
+
+	return transfer, nil
+}
+
+// Subscribe returns a channel receiving the transfer once it is completed or failed, by this queue's
+// workers or, noticed within PollInterval, by any other replica's. The returned function must be
+// called to release the subscription.
+func (q *Queue) Subscribe(id uint) (<-chan models.Transfer, func(), error) {
+	ch := make(chan models.Transfer, 1)
+
+	q.mu.Lock()
+	q.subscribers[id] = append(q.subscribers[id], ch)
+	q.mu.Unlock()
+
+	cancel := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		subscribers := q.subscribers[id]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				q.subscribers[id] = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+		if len(q.subscribers[id]) == 0 {
+			delete(q.subscribers, id)
+		}
+	}
+
+	// The transfer may have been finished before the subscription was registered
+	var transfer models.Transfer
+	if err := q.db.First(&transfer, id).Error; err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("transfer not found: %w", err)
+	}
+	if transfer.Status != models.TransferPending {
+		q.notify(transfer)
+	}
+
+	return ch, cancel, nil
+}
+
+func (q *Queue) notify(transfer models.Transfer) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, ch := range q.subscribers[transfer.ID] {
+		select {
+		case ch <- transfer:
+		default:
+		}
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		var batch []uint
+
+		select {
+		case id := <-q.jobs:
+			batch = append(batch, id)
+		case <-ctx.Done():
+			return
+		}
+
+		// Collect more transfers until the batch is full or the wait time is up
+		timer := time.NewTimer(q.config.BatchWait)
+	collect:
+		for len(batch) < q.config.BatchSize {
+			select {
+			case id := <-q.jobs:
+				batch = append(batch, id)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		q.commit(batch)
+	}
+}
+
+// poll notifies the subscribers of transfers finished by the workers of other replicas, which only
+// notify their own subscribers
+func (q *Queue) poll(ctx context.Context) {
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		q.mu.Lock()
+		ids := make([]uint, 0, len(q.subscribers))
+		for id := range q.subscribers {
+			ids = append(ids, id)
+		}
+		q.mu.Unlock()
+		if len(ids) == 0 {
+			continue
+		}
+
+		var finished []models.Transfer
+		err := q.db.Where("id IN ? AND status <> ?", ids, models.TransferPending).Find(&finished).Error
+		if err != nil {
+			log.Printf("Failed to load subscribed transfers: %v", err)
+			continue
+		}
+		for _, transfer := range finished {
+			q.notify(transfer)
+		}
+	}
+}
+
+func (q *Queue) sweep(ctx context.Context) {
+	ticker := time.NewTicker(q.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		var ids []uint
+		err := q.db.Model(&models.Transfer{}).
+			Where("status = ? AND created_at < ?", models.TransferPending, time.Now().Add(-q.config.SweepInterval)).
+			Order("id").
+			Pluck("id", &ids).Error
+		if err != nil {
+			log.Printf("Failed to load pending transfers: %v", err)
+		}
+		for _, id := range ids {
+			select {
+			case q.jobs <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// commit applies the batch in a single DB transaction. All wallets of the batch are locked up front
+// in alphabetical order, then each transfer runs in its own savepoint so a failed transfer doesn't
+// roll back the others.
+func (q *Queue) commit(ids []uint) {
+	var transfers []models.Transfer
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		// Skip transfers that are being applied by another worker or replica
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id IN ? AND status = ?", ids, models.TransferPending).
+			Order("id").
+			Find(&transfers).Error
+		if err != nil {
+			return fmt.Errorf("failed to load pending transfers: %w", err)
+		}
+		if len(transfers) == 0 {
+			return nil
+		}
+
+		// Transfers charged a fee also credit the treasury, so it's locked in order with the other wallets
+		schedule, err := FeeSchedule(tx)
+		if err != nil {
+			return err
+		}
+		addresses := []string{schedule.Treasury}
+		for _, transfer := range transfers {
+			addresses = append(addresses, transfer.FromAddress, transfer.ToAddress)
+		}
+		if err := lockWallets(tx, addresses...); err != nil {
+			return err
+		}
+
+		for i := range transfers {
+			transfer := &transfers[i]
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				_, err := execute(tx, Pessimistic, transfer)
+				return err
+			})
+			if err != nil {
+				transfer.Status = models.TransferFailed
+				transfer.Reason = err.Error()
+				if err := tx.Save(transfer).Error; err != nil {
+					return fmt.Errorf("failed to record failed transfer: %w", err)
+				}
+				if err := publishTransfer(tx, EventTransferFailed, transfer); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		// The batch as a whole failed (e.g. a deadlock with a sharded wallet), retry transfers one by one
+		log.Printf("Failed to commit batch of %d transfers, retrying one by one: %v", len(ids), err)
+		if len(ids) > 1 {
+			for _, id := range ids {
+				q.commit([]uint{id})
+			}
+		}
+		return
+	}
+
+	for _, transfer := range transfers {
+		q.notify(transfer)
+	}
+}
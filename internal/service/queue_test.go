@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestQueue_GroupCommit(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 100}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := service.NewQueue(testDB, service.QueueConfig{Workers: 2, BatchSize: 50, BatchWait: 20 * time.Millisecond})
+
+	// Submit everything before the workers start so the transfers are committed in batches
+	var transfers []*models.Transfer
+	for i := 0; i < 60; i++ {
+		from, to := "A", "B"
+		if i%2 == 1 {
+			from, to = "B", "C"
+		}
+		transfer, err := queue.Submit(ctx, from, to, 2)
+		require.NoError(t, err)
+		require.Equal(t, models.TransferPending, transfer.Status)
+		transfers = append(transfers, transfer)
+	}
+
+	// A's balance is not enough for this one, it must fail without affecting the rest of its batch
+	overdraft, err := queue.Submit(ctx, "A", "C", 1000)
+	require.NoError(t, err)
+	transfers = append(transfers, overdraft)
+
+	queue.Start(ctx)
+
+	for _, transfer := range transfers {
+		updates, unsubscribe, err := queue.Subscribe(transfer.ID)
+		require.NoError(t, err)
+
+		select {
+		case final := <-updates:
+			if transfer.ID == overdraft.ID {
+				require.Equal(t, models.TransferFailed, final.Status)
+				require.Contains(t, final.Reason, "insufficient balance")
+			} else {
+				require.Equal(t, models.TransferCompleted, final.Status)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("transfer %d was not applied in time", transfer.ID)
+		}
+		unsubscribe()
+	}
+
+	var walletA, walletB, walletC models.Wallet
+	require.NoError(t, testDB.First(&walletA, "address = ?", "A").Error)
+	require.NoError(t, testDB.First(&walletB, "address = ?", "B").Error)
+	require.NoError(t, testDB.First(&walletC, "address = ?", "C").Error)
+
+	require.Equal(t, 40, walletA.Balance)
+	require.Equal(t, 100, walletB.Balance)
+	require.Equal(t, 60, walletC.Balance)
+}
+
+func TestQueue_SubmitValidation(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	queue := service.NewQueue(testDB, service.QueueConfig{})
+
+	_, err := queue.Submit(context.Background(), "A", "B", 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be greater than 0")
+}
+
+func TestQueue_SubmitWhenFull(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The queue holds only 4 transfers, the others must stay pending instead of blocking Submit
+	queue := service.NewQueue(testDB, service.QueueConfig{Workers: 1, BatchSize: 1, SweepInterval: 10 * time.Millisecond})
+
+	var transfers []*models.Transfer
+	for i := 0; i < 10; i++ {
+		transfer, err := queue.Submit(ctx, "A", "B", 1)
+		require.NoError(t, err)
+		require.Equal(t, models.TransferPending, transfer.Status)
+		transfers = append(transfers, transfer)
+	}
+
+	// The sweeper picks up the transfers that didn't fit
+	queue.Start(ctx)
+
+	for _, transfer := range transfers {
+		updates, unsubscribe, err := queue.Subscribe(transfer.ID)
+		require.NoError(t, err)
+
+		select {
+		case final := <-updates:
+			require.Equal(t, models.TransferCompleted, final.Status)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("transfer %d was not applied in time", transfer.ID)
+		}
+		unsubscribe()
+	}
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 10, balance)
+}
+
+func TestQueue_SubscribeToOtherReplica(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The transfer is applied by the workers of one replica and watched through the other
+	applying := service.NewQueue(testDB, service.QueueConfig{Workers: 1})
+	watching := service.NewQueue(testDB, service.QueueConfig{Workers: 1, PollInterval: 10 * time.Millisecond})
+	watching.Start(ctx)
+
+	transfer, err := applying.Submit(ctx, "A", "B", 5)
+	require.NoError(t, err)
+	updates, unsubscribe, err := watching.Subscribe(transfer.ID)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	applying.Start(ctx)
+
+	select {
+	case final := <-updates:
+		require.Equal(t, models.TransferCompleted, final.Status)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("transfer %d was not notified in time", transfer.ID)
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// Discrepancy is a wallet whose balance doesn't match the balance derived from the ledger
+type Discrepancy = models.Discrepancy
+
+// ReconcileReport is the result of checking the balances against the ledger
+type ReconcileReport struct {
+	// TotalSupply is the minted minus the burned amount
+	TotalSupply int `json:"totalSupply"`
+	// TotalBalance is the sum of all wallet balances, including held balances and the slots of sharded wallets
+	TotalBalance int `json:"totalBalance"`
+	// Discrepancies are the wallets whose balance, including the held balance, differs from their ledger-derived balance
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	// NegativeBalances are the addresses of wallets with a negative balance or slot
+	NegativeBalances []string `json:"negativeBalances"`
+}
+
+// OK reports whether all invariants hold
+func (r *ReconcileReport) OK() bool {
+	return r.TotalSupply == r.TotalBalance && len(r.Discrepancies) == 0 && len(r.NegativeBalances) == 0
+}
+
+// Reconcile checks the balances against the settled ledger entries, all from the same snapshot
+func Reconcile(db *gorm.DB) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Transfer{}).
+			Select("COALESCE(SUM(CASE kind WHEN ? THEN amount WHEN ? THEN -amount ELSE 0 END), 0)", models.KindMint, models.KindBurn).
+			Where("status IN ?", models.SettledStatuses).
+			Scan(&report.TotalSupply).Error
+		if err != nil {
+			return fmt.Errorf("failed to sum total supply: %w", err)
+		}
+
+		err = tx.Raw("SELECT (SELECT COALESCE(SUM(balance + held), 0) FROM wallets) + (SELECT COALESCE(SUM(balance), 0) FROM wallet_shards)").
+			Scan(&report.TotalBalance).Error
+		if err != nil {
+			return fmt.Errorf("failed to sum balances: %w", err)
+		}
+
+		err = tx.Raw(`
+			WITH ledger AS (
+				SELECT to_address AS address, amount FROM transfers WHERE status IN @settled AND to_address <> ''
+				UNION ALL
+				SELECT from_address, -amount FROM transfers WHERE status IN @settled AND from_address <> ''
+			), derived AS (
+				SELECT address, SUM(amount) AS balance FROM ledger GROUP BY address
+			), actual AS (
+				SELECT w.address, w.balance + w.held + COALESCE(SUM(s.balance), 0) AS balance
+				FROM wallets w LEFT JOIN wallet_shards s ON s.address = w.address
+				GROUP BY w.address, w.balance, w.held
+			)
+			SELECT COALESCE(a.address, d.address) AS address, COALESCE(a.balance, 0) AS balance, COALESCE(d.balance, 0) AS ledger_balance
+			FROM actual a FULL OUTER JOIN derived d ON a.address = d.address
+			WHERE COALESCE(a.balance, 0) <> COALESCE(d.balance, 0)
+			ORDER BY 1`,
+			sql.Named("settled", models.SettledStatuses),
+		).Scan(&report.Discrepancies).Error
+		if err != nil {
+			return fmt.Errorf("failed to compare balances with the ledger: %w", err)
+		}
+
+		err = tx.Raw(`
+			SELECT address FROM wallets WHERE balance < 0 OR held < 0
+			UNION
+			SELECT address FROM wallet_shards WHERE balance < 0
+			ORDER BY 1`,
+		).Scan(&report.NegativeBalances).Error
+		if err != nil {
+			return fmt.Errorf("failed to find negative balances: %w", err)
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// reconcileMetrics are published on /debug/vars along with the other expvar variables
+var reconcileMetrics = expvar.NewMap("reconcile")
+
+// ReconcilerActor is recorded in the audit log when a strict reconciliation pauses transfers
+const ReconcilerActor = "reconciler"
+
+// RecordReconcile checks the balances against the ledger and stores the result as a ReconcileRun.
+// In strict mode, a failed check pauses all transfers until an admin resumes them.
+func RecordReconcile(db *gorm.DB, strict bool) (*models.ReconcileRun, error) {
+	report, err := Reconcile(db)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.ReconcileRun{
+		OK:               report.OK(),
+		TotalSupply:      report.TotalSupply,
+		TotalBalance:     report.TotalBalance,
+		Discrepancies:    report.Discrepancies,
+		NegativeBalances: report.NegativeBalances,
+	}
+	if err := db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record reconcile run: %w", err)
+	}
+	publishReconcile(run)
+
+	if strict && !run.OK {
+		state, err := PauseState(db)
+		if err != nil {
+			return run, err
+		}
+		if !state.Paused {
+			reason := fmt.Sprintf("reconcile run %d found the balances drifting from the ledger", run.ID)
+			if _, err := Pause(db, reason, ReconcilerActor); err != nil {
+				return run, err
+			}
+			run.Paused = true
+			if err := db.Model(run).Update("paused", true).Error; err != nil {
+				return run, fmt.Errorf("failed to record reconcile run: %w", err)
+			}
+		}
+	}
+	return run, nil
+}
+
+// ReconcileRuns returns up to limit recorded runs, newest first, only the failed ones if failedOnly is set
+func ReconcileRuns(db *gorm.DB, failedOnly bool, limit int) ([]models.ReconcileRun, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	query := db.Order("id DESC").Limit(limit)
+	if failedOnly {
+		query = query.Where("ok = ?", false)
+	}
+
+	var runs []models.ReconcileRun
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reconcile runs: %w", err)
+	}
+	return runs, nil
+}
+
+func publishReconcile(run *models.ReconcileRun) {
+	reconcileMetrics.Add("runs", 1)
+	if !run.OK {
+		reconcileMetrics.Add("failures", 1)
+	}
+	setInt := func(key string, value int) {
+		v := new(expvar.Int)
+		v.Set(int64(value))
+		reconcileMetrics.Set(key, v)
+	}
+	setInt("discrepancies", len(run.Discrepancies))
+	setInt("negative_balances", len(run.NegativeBalances))
+	setInt("supply_drift", run.TotalBalance-run.TotalSupply)
+	setInt("last_run_timestamp", int(run.CreatedAt.Unix()))
+}
+
+// Reconciler periodically records a reconciliation of the balances against the ledger
+type Reconciler struct {
+	db       *gorm.DB
+	interval time.Duration
+	strict   bool
+}
+
+// NewReconciler creates a reconciler running every interval. In strict mode it pauses transfers on drift.
+func NewReconciler(db *gorm.DB, interval time.Duration, strict bool) *Reconciler {
+	return &Reconciler{db: db, interval: interval, strict: strict}
+}
+
+// Start runs the reconciler until the context is cancelled
+func (r *Reconciler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			run, err := RecordReconcile(r.db.WithContext(ctx), r.strict)
+			if err != nil {
+				log.Printf("Failed to reconcile the ledger: %v", err)
+				continue
+			}
+			if !run.OK {
+				log.Printf("Reconcile run %d found %d discrepancies, %d negative balances and a supply drift of %d",
+					run.ID, len(run.Discrepancies), len(run.NegativeBalances), run.TotalBalance-run.TotalSupply)
+			}
+		}
+	}()
+}
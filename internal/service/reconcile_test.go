@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestReconcile(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	_, err = service.Transfer(testDB, "A", "B", 30)
+	require.NoError(t, err)
+	_, err = service.EnableSharding(testDB, "B", 3)
+	require.NoError(t, err)
+
+	report, err := service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%+v", report)
+	require.Equal(t, 100, report.TotalSupply)
+
+	// A balance changed outside of the ledger is reported
+	require.NoError(t, testDB.Model(&models.Wallet{}).Where("address = ?", "A").Update("balance", 75).Error)
+
+	report, err = service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, 105, report.TotalBalance)
+	require.Equal(t, []service.Discrepancy{{Address: "A", Balance: 75, LedgerBalance: 70}}, report.Discrepancies)
+}
+
+func TestRecordReconcile_Strict(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+
+	run, err := service.RecordReconcile(testDB, true)
+	require.NoError(t, err)
+	require.True(t, run.OK)
+	require.False(t, run.Paused)
+
+	require.NoError(t, testDB.Model(&models.Wallet{}).Where("address = ?", "A").Update("balance", 90).Error)
+
+	// Drift is recorded and pauses transfers in strict mode
+	run, err = service.RecordReconcile(testDB, true)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := service.Resume(testDB, "reconcile test done", "test")
+		require.NoError(t, err)
+	})
+	require.False(t, run.OK)
+	require.True(t, run.Paused)
+
+	_, err = service.Transfer(testDB, "A", "B", 1)
+	require.ErrorIs(t, err, service.ErrPaused)
+
+	runs, err := service.ReconcileRuns(testDB, true, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, []service.Discrepancy{{Address: "A", Balance: 90, LedgerBalance: 100}}, runs[0].Discrepancies)
+}
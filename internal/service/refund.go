@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// ErrNotRefundable is returned when reversing or refunding an entry that isn't a completed transfer,
+// or whose amount was already sent back in full
+var ErrNotRefundable = errors.New("transfer can't be refunded")
+
+// ReverseTransfer sends the whole remaining amount of the transfer back to its sender as a REVERSAL entry,
+// and records who did it and why. The fee stays with the treasury, and the reversal itself is free.
+func ReverseTransfer(db *gorm.DB, id uint, reason string, actor string) (*models.Transfer, error) {
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+	return compensate(db, id, models.KindReversal, 0, reason, actor)
+}
+
+// Refund sends the amount of the transfer back from its receiver to its sender as a REFUND entry.
+// The receiver is charged the fee like for a transfer.
+func Refund(db *gorm.DB, id uint, amount int) (*models.Transfer, error) {
+	if amount < 1 {
+		return nil, invalidArgument("refund amount must be greater than 0")
+	}
+	return compensate(db, id, models.KindRefund, amount, "", "")
+}
+
+// RefundRemaining refunds everything of the transfer not refunded yet, like Refund
+func RefundRemaining(db *gorm.DB, id uint) (*models.Transfer, error) {
+	return compensate(db, id, models.KindRefund, 0, "", "")
+}
+
+// compensate moves the amount back from the receiver to the sender of the transfer in a new entry
+// linked to it, and updates the status of the transfer. A zero amount sends back everything not sent
+// back yet. The ledger entries themselves are never changed.
+func compensate(db *gorm.DB, id uint, kind models.TransferKind, amount int, reason string, actor string) (*models.Transfer, error) {
+	var entry *models.Transfer
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// The lock serializes concurrent refunds of the same transfer
+		var original models.Transfer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, id).Error; err != nil {
+			return fmt.Errorf("transfer not found: %w", err)
+		}
+		if original.Kind != models.KindTransfer {
+			return fmt.Errorf("%w: %s entries can't be refunded", ErrNotRefundable, original.Kind)
+		}
+		if original.Status != models.TransferCompleted && original.Status != models.TransferPartiallyRefunded {
+			return fmt.Errorf("%w: %s", ErrNotRefundable, original.Status)
+		}
+
+		remaining := original.Amount - original.Refunded
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return invalidArgument("refund amount must be between 1 and %d", remaining)
+		}
+
+		// Reversals correct a mistake of the sender, so only refunds are charged a fee
+		schedule, err := FeeSchedule(tx)
+		if err != nil {
+			return err
+		}
+		charged := 0
+		if kind == models.KindRefund {
+			charged = fee(schedule, original.ToAddress, amount)
+		}
+
+		sharded, err := shardedWallets(tx, original.ToAddress, original.FromAddress)
+		if err != nil {
+			return err
+		}
+		if err := checkTransferable(tx, original.ToAddress, original.FromAddress); err != nil {
+			return err
+		}
+		if charged > 0 {
+			if err := lockWallets(tx, original.ToAddress, original.FromAddress, schedule.Treasury); err != nil {
+				return err
+			}
+		}
+		if len(sharded) > 0 {
+			_, err = transferSharded(tx, original.ToAddress, original.FromAddress, amount, sharded)
+		} else {
+			_, err = DefaultStrategy.transfer(tx, original.ToAddress, original.FromAddress, amount)
+		}
+		if err != nil {
+			return err
+		}
+		if charged > 0 {
+			if _, err := chargeFee(tx, original.ToAddress, schedule.Treasury, amount, charged); err != nil {
+				return err
+			}
+		}
+
+		entry = &models.Transfer{
+			Kind:        kind,
+			FromAddress: original.ToAddress,
+			ToAddress:   original.FromAddress,
+			Amount:      amount,
+			Fee:         charged,
+			Status:      models.TransferCompleted,
+			Reason:      reason,
+			ParentID:    &original.ID,
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return fmt.Errorf("failed to record %s: %w", kind, err)
+		}
+		if err := publishTransfer(tx, EventTransferCompleted, entry); err != nil {
+			return err
+		}
+		if charged > 0 {
+			if err := recordFee(tx, entry, schedule.Treasury); err != nil {
+				return err
+			}
+		}
+		err = appendEvent(tx, models.EventTransferExecuted, models.EventData{
+			From:       entry.FromAddress,
+			To:         entry.ToAddress,
+			Amount:     amount,
+			Kind:       kind,
+			TransferID: &entry.ID,
+			Fee:        charged,
+			Treasury:   schedule.Treasury,
+		})
+		if err != nil {
+			return err
+		}
+
+		original.Refunded += amount
+		switch {
+		case kind == models.KindReversal:
+			original.Status = models.TransferReversed
+		case original.Refunded == original.Amount:
+			original.Status = models.TransferRefunded
+		default:
+			original.Status = models.TransferPartiallyRefunded
+		}
+		if err := tx.Model(&original).Select("refunded", "status").Updates(&original).Error; err != nil {
+			return fmt.Errorf("failed to update transfer: %w", err)
+		}
+
+		if kind == models.KindReversal {
+			return audit(tx, models.AuditRecord{Action: models.AuditReverse, Address: original.FromAddress, Reason: reason, Actor: actor})
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
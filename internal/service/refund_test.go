@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestRefund_Partial(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 50)
+	require.NoError(t, err)
+
+	refund, err := service.Refund(testDB, entry.ID, 20)
+	require.NoError(t, err)
+	require.Equal(t, models.KindRefund, refund.Kind)
+	require.Equal(t, entry.ID, *refund.ParentID)
+
+	var original models.Transfer
+	require.NoError(t, testDB.First(&original, entry.ID).Error)
+	require.Equal(t, models.TransferPartiallyRefunded, original.Status)
+	require.Equal(t, 20, original.Refunded)
+
+	// No more than the rest of the amount can be refunded
+	_, err = service.Refund(testDB, entry.ID, 31)
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	// Refunding nothing is a mistake rather than a refund of everything
+	_, err = service.Refund(testDB, entry.ID, 0)
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	_, err = service.RefundRemaining(testDB, entry.ID)
+	require.NoError(t, err)
+	require.NoError(t, testDB.First(&original, entry.ID).Error)
+	require.Equal(t, models.TransferRefunded, original.Status)
+
+	_, err = service.Refund(testDB, entry.ID, 1)
+	require.ErrorIs(t, err, service.ErrNotRefundable)
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 100, balance)
+
+	report, err := service.Reconcile(testDB)
+	require.NoError(t, err)
+	require.Empty(t, report.Discrepancies)
+}
+
+func TestReverseTransfer(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 50)
+	require.NoError(t, err)
+	_, err = service.Refund(testDB, entry.ID, 10)
+	require.NoError(t, err)
+
+	reversal, err := service.ReverseTransfer(testDB, entry.ID, "wrong receiver", "admin")
+	require.NoError(t, err)
+	require.Equal(t, models.KindReversal, reversal.Kind)
+	require.Equal(t, 40, reversal.Amount)
+
+	var original models.Transfer
+	require.NoError(t, testDB.First(&original, entry.ID).Error)
+	require.Equal(t, models.TransferReversed, original.Status)
+
+	records, err := service.AuditLog(testDB, "A", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, models.AuditReverse, records[0].Action)
+}
+
+func TestRefund_ChargesFee(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.SetFeeSchedule(testDB, models.FeeSchedule{Treasury: "T", Flat: 1})
+	require.NoError(t, err)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 10}).Error)
+
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 50)
+	require.NoError(t, err)
+
+	// The receiver pays the fee of the refund, the reversal of the rest is free
+	refund, err := service.Refund(testDB, entry.ID, 20)
+	require.NoError(t, err)
+	require.Equal(t, 1, refund.Fee)
+
+	reversal, err := service.ReverseTransfer(testDB, entry.ID, "mistake", "admin")
+	require.NoError(t, err)
+	require.Zero(t, reversal.Fee)
+
+	for address, expected := range map[string]int{"A": 99, "B": 9, "T": 2} {
+		balance, err := service.Balance(testDB, address)
+		require.NoError(t, err)
+		require.Equal(t, expected, balance, address)
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// ErrNotScheduled is returned when cancelling an instruction that was already executed or cancelled
+var ErrNotScheduled = errors.New("transfer is no longer scheduled")
+
+// SchedulerConfig configures how due scheduled transfers are picked up
+type SchedulerConfig struct {
+	// Interval is how often the scheduler looks for due instructions
+	Interval time.Duration
+	// BatchSize is the maximum number of instructions executed in a single DB transaction
+	BatchSize int
+	// CheckpointInterval is how often the scheduler writes a round of balance checkpoints
+	CheckpointInterval time.Duration
+}
+
+// DefaultSchedulerConfig is used for every zero field of the SchedulerConfig passed to NewScheduler
+var DefaultSchedulerConfig = SchedulerConfig{
+	Interval:           time.Second,
+	BatchSize:          100,
+	CheckpointInterval: time.Hour,
+}
+
+// ScheduleTransfer persists an instruction to transfer the tokens at executeAt
+func ScheduleTransfer(db *gorm.DB, from string, to string, amount int, executeAt time.Time) (*models.ScheduledTransfer, error) {
+	if err := validate(from, to, amount); err != nil {
+		return nil, err
+	}
+
+	scheduled := &models.ScheduledTransfer{
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount,
+		ExecuteAt:   executeAt,
+		Status:      models.ScheduledPending,
+	}
+	if err := db.Create(scheduled).Error; err != nil {
+		return nil, fmt.Errorf("failed to schedule transfer: %w", err)
+	}
+	return scheduled, nil
+}
+
+// CancelScheduledTransfer cancels the instruction unless it was already executed
+func CancelScheduledTransfer(db *gorm.DB, id uint) (*models.ScheduledTransfer, error) {
+	// The update waits for a scheduler executing the instruction and then sees its new status
+	res := db.Model(&models.ScheduledTransfer{}).
+		Where("id = ? AND status = ?", id, models.ScheduledPending).
+		Update("status", models.ScheduledCancelled)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to cancel scheduled transfer: %w", res.Error)
+	}
+
+	scheduled, err := ScheduledTransfer(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotScheduled, scheduled.Status)
+	}
+	return scheduled, nil
+}
+
+// ScheduledTransfer loads the instruction with the given ID
+func ScheduledTransfer(db *gorm.DB, id uint) (*models.ScheduledTransfer, error) {
+	var scheduled models.ScheduledTransfer
+	if err := db.First(&scheduled, id).Error; err != nil {
+		return nil, fmt.Errorf("scheduled transfer not found: %w", err)
+	}
+	return &scheduled, nil
+}
+
+// Scheduler executes scheduled transfers and standing orders once they are due, releases expired holds
+// and writes balance checkpoints. Any number of schedulers
+// may run in parallel, e.g. one per replica, each instruction is claimed by exactly one of them.
+type Scheduler struct {
+	db     *gorm.DB
+	config SchedulerConfig
+}
+
+func NewScheduler(db *gorm.DB, config SchedulerConfig) *Scheduler {
+	if config.Interval <= 0 {
+		config.Interval = DefaultSchedulerConfig.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultSchedulerConfig.BatchSize
+	}
+	if config.CheckpointInterval <= 0 {
+		config.CheckpointInterval = DefaultSchedulerConfig.CheckpointInterval
+	}
+	return &Scheduler{db: db, config: config}
+}
+
+// Start runs the scheduler until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+		var checkpointed time.Time
+
+		for {
+			s.drain("scheduled transfers", s.RunDue)
+			s.drain("standing orders", s.RunStandingOrders)
+			s.drain("hold expiry", s.ExpireHolds)
+
+			if time.Since(checkpointed) >= s.config.CheckpointInterval {
+				if _, err := CheckpointBalances(s.db); err != nil {
+					log.Printf("Failed to checkpoint balances: %v", err)
+				}
+				checkpointed = time.Now()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// paused reports whether balance changes are paused, due instructions then wait instead of failing
+func (s *Scheduler) paused() (bool, error) {
+	state, err := PauseState(s.db)
+	if err != nil {
+		return false, err
+	}
+	return state.Paused, nil
+}
+
+// drain keeps running the batches while full batches are due
+func (s *Scheduler) drain(name string, run func(now time.Time) (int, error)) {
+	for {
+		executed, err := run(time.Now())
+		if err != nil {
+			log.Printf("Failed to run %s: %v", name, err)
+		}
+		if err != nil || executed < s.config.BatchSize {
+			return
+		}
+	}
+}
+
+// RunDue executes a batch of the instructions due at the given time and returns their number.
+// The instructions stay locked until their outcome is committed, instructions locked by another
+// scheduler are skipped.
+func (s *Scheduler) RunDue(now time.Time) (int, error) {
+	if paused, err := s.paused(); paused || err != nil {
+		return 0, err
+	}
+
+	var due []models.ScheduledTransfer
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND execute_at <= ?", models.ScheduledPending, now).
+			Order("execute_at, id").
+			Limit(s.config.BatchSize).
+			Find(&due).Error
+		if err != nil {
+			return fmt.Errorf("failed to load due scheduled transfers: %w", err)
+		}
+
+		for i := range due {
+			scheduled := &due[i]
+
+			// The transfer runs in a savepoint, a failed transfer doesn't roll back the others
+			entry := &models.Transfer{
+				Kind:        models.KindTransfer,
+				FromAddress: scheduled.FromAddress,
+				ToAddress:   scheduled.ToAddress,
+				Amount:      scheduled.Amount,
+			}
+			if _, err := transferEntry(tx, DefaultStrategy, entry); err != nil {
+				scheduled.Status = models.ScheduledFailed
+				scheduled.Reason = err.Error()
+			} else {
+				scheduled.Status = models.ScheduledCompleted
+				scheduled.TransferID = &entry.ID
+			}
+
+			if err := tx.Save(scheduled).Error; err != nil {
+				return fmt.Errorf("failed to record scheduled transfer: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return len(due), nil
+}
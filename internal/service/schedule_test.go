@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestScheduler_RunDue(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	now := time.Now()
+	due, err := service.ScheduleTransfer(testDB, "A", "B", 4, now.Add(-time.Minute))
+	require.NoError(t, err)
+	overdraft, err := service.ScheduleTransfer(testDB, "A", "B", 100, now.Add(-time.Second))
+	require.NoError(t, err)
+	later, err := service.ScheduleTransfer(testDB, "A", "B", 1, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	executed, err := service.NewScheduler(testDB, service.SchedulerConfig{}).RunDue(now)
+	require.NoError(t, err)
+	require.Equal(t, 2, executed)
+
+	due, err = service.ScheduledTransfer(testDB, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScheduledCompleted, due.Status)
+	require.NotNil(t, due.TransferID)
+
+	overdraft, err = service.ScheduledTransfer(testDB, overdraft.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScheduledFailed, overdraft.Status)
+	require.Contains(t, overdraft.Reason, "insufficient balance")
+
+	later, err = service.ScheduledTransfer(testDB, later.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScheduledPending, later.Status)
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 4, balance)
+
+	// Executed transfers can't be cancelled anymore, pending ones can
+	_, err = service.CancelScheduledTransfer(testDB, due.ID)
+	require.ErrorIs(t, err, service.ErrNotScheduled)
+	later, err = service.CancelScheduledTransfer(testDB, later.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScheduledCancelled, later.Status)
+
+	executed, err = service.NewScheduler(testDB, service.SchedulerConfig{}).RunDue(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, executed)
+}
+
+func TestScheduler_ConcurrentSchedulersExecuteOnce(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+	for i := 0; i < 50; i++ {
+		_, err := service.ScheduleTransfer(testDB, "A", "B", 1, time.Now().Add(-time.Second))
+		require.NoError(t, err)
+	}
+
+	errs := make(chan error, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler := service.NewScheduler(testDB, service.SchedulerConfig{BatchSize: 5})
+			for {
+				executed, err := scheduler.RunDue(time.Now())
+				if err != nil || executed == 0 {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 50, balance)
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/rand/v2"
+	"slices"
+	"token-transfer-api/internal/models"
+)
+
+// MaxShards is the maximum number of slots a single wallet can be split into
+const MaxShards = 256
+
+// EnableSharding splits the balance of the wallet evenly across the given number of slots.
+// Calling it on an already sharded wallet re-splits the whole balance across the new number of slots.
+func EnableSharding(db *gorm.DB, address string, slots int) (*models.Wallet, error) {
+	if slots < 1 || slots > MaxShards {
+		return nil, invalidArgument("number of slots must be between 1 and %d", MaxShards)
+	}
+	return reshard(db, address, slots)
+}
+
+// DisableSharding folds all slots of the wallet back into its single balance row
+func DisableSharding(db *gorm.DB, address string) (*models.Wallet, error) {
+	return reshard(db, address, 0)
+}
+
+func reshard(db *gorm.DB, address string, slots int) (*models.Wallet, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var wallet models.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, "address = ?", address).Error; err != nil {
+			return fmt.Errorf("%w: %w", ErrWalletNotFound, err)
+		}
+
+		shards, err := lockShards(tx, address)
+		if err != nil {
+			return err
+		}
+
+		total := wallet.Balance
+		for _, shard := range shards {
+			total += shard.Balance
+		}
+
+		if err := tx.Where("address = ?", address).Delete(&models.WalletShard{}).Error; err != nil {
+			return fmt.Errorf("failed to delete wallet slots: %w", err)
+		}
+
+		wallet.Balance = total
+		if slots > 0 {
+			if err := tx.Create(spread(address, total, slots)).Error; err != nil {
+				return fmt.Errorf("failed to create wallet slots: %w", err)
+			}
+			wallet.Balance = 0
+		}
+		wallet.Shards = slots
+		wallet.Version++
+
+		if err := tx.Save(&wallet).Error; err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Wallet(db, address)
+}
+
+// RebalanceShards evens out the balance between the slots of a sharded wallet
+func RebalanceShards(db *gorm.DB, address string) (*models.Wallet, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		shards, err := lockShards(tx, address)
+		if err != nil {
+			return err
+		}
+		if len(shards) == 0 {
+			return invalidArgument("wallet %s is not sharded", address)
+		}
+		return rebalance(tx, shards)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Wallet(db, address)
+}
+
+// Wallet loads the wallet with its aggregate balance, including all of its slots
+func Wallet(db *gorm.DB, address string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := db.First(&wallet, "address = ?", address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrWalletNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to load wallet: %w", err)
+	}
+	if wallet.Shards == 0 {
+		return &wallet, nil
+	}
+
+	shardBalance, err := sumShards(db, address)
+	if err != nil {
+		return nil, err
+	}
+	wallet.Balance += shardBalance
+	return &wallet, nil
+}
+
+// ListWallets returns up to limit wallets with their aggregate balances in address order,
+// starting after the given address
+func ListWallets(db *gorm.DB, after string, limit int) ([]models.Wallet, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	var wallets []models.Wallet
+	if err := db.Where("address > ?", after).Order("address").Limit(limit).Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+
+	for i := range wallets {
+		if wallets[i].Shards == 0 {
+			continue
+		}
+		shardBalance, err := sumShards(db, wallets[i].Address)
+		if err != nil {
+			return nil, err
+		}
+		wallets[i].Balance += shardBalance
+	}
+	return wallets, nil
+}
+
+// Balance returns the aggregate balance of the wallet, including all of its slots
+func Balance(db *gorm.DB, address string) (int, error) {
+	wallet, err := Wallet(db, address)
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance, nil
+}
+
+// shardedWallets returns the number of slots of the sender and the receiver, if they are sharded.
+// An empty address stands for no sender or receiver. The wallet rows are locked FOR KEY SHARE, which
+// doesn't block the balance updates of other transfers but keeps the wallets from being resharded or
+// frozen until the transaction ends, so the slot counts stay valid and checkTransferable can run next.
+func shardedWallets(tx *gorm.DB, from string, to string) (map[string]int, error) {
+	var wallets []models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "KEY SHARE"}).
+		Select("address", "shards").
+		Where("address IN ?", nonEmpty(from, to)).
+		Order("address").
+		Find(&wallets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallets: %w", err)
+	}
+
+	sharded := make(map[string]int, len(wallets))
+	for _, wallet := range wallets {
+		if wallet.Shards > 0 {
+			sharded[wallet.Address] = wallet.Shards
+		}
+	}
+	return sharded, nil
+}
+
+// checkTransferable refuses the balance change while transfers are paused, or if either side is
+// blocklisted or frozen in its direction. An empty address stands for no sender or receiver.
+// It must run after the wallet rows are locked, so a freeze either waits for the change or is seen by it.
+func checkTransferable(tx *gorm.DB, from string, to string) error {
+	if err := checkPaused(tx); err != nil {
+		return err
+	}
+
+	addresses := nonEmpty(from, to)
+	for _, address := range addresses {
+		if Blocked(address) {
+			return fmt.Errorf("%w: %s", ErrBlocked, address)
+		}
+	}
+
+	var frozen []models.Wallet
+	err := tx.Select("address", "send_frozen", "receive_frozen", "freeze_reason").
+		Where("address IN ? AND (send_frozen OR receive_frozen)", addresses).
+		Find(&frozen).Error
+	if err != nil {
+		return fmt.Errorf("failed to load wallets: %w", err)
+	}
+	for _, wallet := range frozen {
+		if wallet.Address == from && wallet.SendFrozen {
+			return fmt.Errorf("%w for sending: %s: %s", ErrWalletFrozen, wallet.Address, wallet.FreezeReason)
+		}
+		if wallet.Address == to && wallet.ReceiveFrozen {
+			return fmt.Errorf("%w for receiving: %s: %s", ErrWalletFrozen, wallet.Address, wallet.FreezeReason)
+		}
+	}
+	return nil
+}
+
+func nonEmpty(addresses ...string) []string {
+	return slices.DeleteFunc(addresses, func(address string) bool { return address == "" })
+}
+
+// transferSharded moves the tokens when at least one side of the transfer is sharded.
+// Both sides are still handled in alphabetical order of addresses to avoid deadlocks.
+func transferSharded(tx *gorm.DB, from string, to string, amount int, sharded map[string]int) (int, error) {
+	var balance int
+
+	debit := func() error {
+		var err error
+		if _, ok := sharded[from]; ok {
+			balance, err = debitShards(tx, from, amount)
+		} else {
+			balance, err = singleStatement{}.debit(tx, from, amount)
+		}
+		return err
+	}
+	credit := func() error {
+		if slots, ok := sharded[to]; ok {
+			return creditShards(tx, to, slots, amount)
+		}
+		return singleStatement{}.credit(tx, to, amount)
+	}
+
+	steps := []func() error{debit, credit}
+	if to < from {
+		steps[0], steps[1] = credit, debit
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+// debitShards takes the amount from a random slot that can cover it on its own. If no such slot is
+// free, all slots are locked, the amount is taken from them together and the slots are rebalanced,
+// so the transfer only fails when the aggregate balance is insufficient.
+func debitShards(tx *gorm.DB, address string, amount int) (int, error) {
+	var taken []int
+	err := tx.Raw(
+		"UPDATE wallet_shards SET balance = balance - ? WHERE (address, slot) = "+
+			"(SELECT address, slot FROM wallet_shards WHERE address = ? AND balance >= ? ORDER BY random() LIMIT 1 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING slot",
+		amount, address, amount,
+	).Scan(&taken).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to update sender slot: %w", err)
+	}
+
+	if len(taken) == 0 {
+		shards, err := lockShards(tx, address)
+		if err != nil {
+			return 0, err
+		}
+
+		total := 0
+		for _, shard := range shards {
+			total += shard.Balance
+		}
+		if total < amount {
+			return 0, &InsufficientBalanceError{Required: amount, Available: total}
+		}
+
+		// Take the amount out of the slots and spread the rest evenly again
+		shards[0].Balance -= amount
+		if err := rebalance(tx, shards); err != nil {
+			return 0, err
+		}
+		return total - amount, nil
+	}
+
+	return sumShards(tx, address)
+}
+
+// creditShards adds the amount to a random free slot, or waits for a random slot if all are locked.
+// The caller must hold a lock on the wallet row, so the number of slots can't change in between.
+func creditShards(tx *gorm.DB, address string, slots int, amount int) error {
+	res := tx.Exec(
+		"UPDATE wallet_shards SET balance = balance + ? WHERE (address, slot) = "+
+			"(SELECT address, slot FROM wallet_shards WHERE address = ? ORDER BY random() LIMIT 1 FOR UPDATE SKIP LOCKED)",
+		amount, address,
+	)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update receiver slot: %w", res.Error)
+	}
+	if res.RowsAffected == 1 {
+		return nil
+	}
+
+	slot := rand.IntN(slots)
+	res = tx.Model(&models.WalletShard{}).
+		Where("address = ? AND slot = ?", address, slot).
+		Update("balance", gorm.Expr("balance + ?", amount))
+	if res.Error != nil {
+		return fmt.Errorf("failed to update receiver slot: %w", res.Error)
+	}
+	if res.RowsAffected != 1 {
+		return fmt.Errorf("failed to update receiver slot: wallet %s has no slot %d", address, slot)
+	}
+	return nil
+}
+
+// lockShards locks all slots of the wallet in slot order
+func lockShards(tx *gorm.DB, address string) ([]models.WalletShard, error) {
+	var shards []models.WalletShard
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("address = ?", address).
+		Order("slot").
+		Find(&shards).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallet slots: %w", err)
+	}
+	return shards, nil
+}
+
+// rebalance spreads the total balance of the locked slots evenly and saves the slots that changed
+func rebalance(tx *gorm.DB, shards []models.WalletShard) error {
+	total := 0
+	for _, shard := range shards {
+		total += shard.Balance
+	}
+
+	for i, target := range spread(shards[0].Address, total, len(shards)) {
+		if shards[i].Balance == target.Balance {
+			continue
+		}
+		err := tx.Model(&models.WalletShard{}).
+			Where("address = ? AND slot = ?", shards[i].Address, shards[i].Slot).
+			Update("balance", target.Balance).Error
+		if err != nil {
+			return fmt.Errorf("failed to rebalance wallet slots: %w", err)
+		}
+	}
+	return nil
+}
+
+// spread splits the total into the given number of slots, the remainder goes to the first slots
+func spread(address string, total int, slots int) []models.WalletShard {
+	shards := make([]models.WalletShard, slots)
+	for i := range shards {
+		shards[i] = models.WalletShard{Address: address, Slot: i, Balance: total / slots}
+		if i < total%slots {
+			shards[i].Balance++
+		}
+	}
+	return shards
+}
+
+func sumShards(tx *gorm.DB, address string) (int, error) {
+	var total int
+	err := tx.Model(&models.WalletShard{}).
+		Select("COALESCE(SUM(balance), 0)").
+		Where("address = ?", address).
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum wallet slots: %w", err)
+	}
+	return total, nil
+}
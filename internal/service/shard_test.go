@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestEnableSharding_SplitsBalance(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	wallet, err := service.EnableSharding(testDB, "A", 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, wallet.Shards)
+	require.Equal(t, 10, wallet.Balance)
+
+	var shards []models.WalletShard
+	require.NoError(t, testDB.Order("slot").Find(&shards, "address = ?", "A").Error)
+	require.Len(t, shards, 4)
+	require.Equal(t, []int{3, 3, 2, 2}, []int{shards[0].Balance, shards[1].Balance, shards[2].Balance, shards[3].Balance})
+
+	wallet, err = service.DisableSharding(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 0, wallet.Shards)
+	require.Equal(t, 10, wallet.Balance)
+}
+
+func TestTransfer_ShardedSenderUsesAggregateBalance(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	_, err := service.EnableSharding(testDB, "A", 4)
+	require.NoError(t, err)
+
+	// No single slot holds 9 tokens, but the slots together do
+	newBalance, err := service.Transfer(testDB, "A", "B", 9)
+	require.NoError(t, err)
+	require.Equal(t, 1, newBalance)
+
+	_, err = service.Transfer(testDB, "A", "B", 2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "insufficient balance: required 2, available 1")
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 1, balance)
+}
+
+func TestTransfer_ShardedReceiver(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 0}).Error)
+	_, err := service.EnableSharding(testDB, "B", 3)
+	require.NoError(t, err)
+
+	_, err = service.Transfer(testDB, "A", "B", 7)
+	require.NoError(t, err)
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 7, balance)
+
+	_, err = service.RebalanceShards(testDB, "B")
+	require.NoError(t, err)
+
+	var shards []models.WalletShard
+	require.NoError(t, testDB.Order("slot").Find(&shards, "address = ?", "B").Error)
+	require.Equal(t, []int{3, 2, 2}, []int{shards[0].Balance, shards[1].Balance, shards[2].Balance})
+}
+
+func TestTransfer_ConcurrentShardedHotWallet(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+	_, err := service.EnableSharding(testDB, "A", 8)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1200)
+
+	// Only 1000 of the 1200 transfers can succeed, the aggregate balance must never go negative
+	for i := 0; i < 1200; i++ {
+		go func() {
+			defer wg.Done()
+			_, _ = service.Transfer(testDB, "A", "C", 1)
+		}()
+	}
+
+	wg.Wait()
+
+	balance, err := service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 0, balance)
+
+	var walletC models.Wallet
+	require.NoError(t, testDB.First(&walletC, "address = ?", "C").Error)
+	require.Equal(t, 1000, walletC.Balance)
+}
+
+func TestTransfer_ConcurrentReshard(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 0}).Error)
+	_, err := service.EnableSharding(testDB, "B", 4)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 220)
+
+	// The receiver is resharded and unsharded while the credits are in flight, none of them may be lost
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.Transfer(testDB, "A", "B", 1); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = service.DisableSharding(testDB, "B")
+			} else {
+				_, err = service.EnableSharding(testDB, "B", 1+i%8)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 200, balance)
+
+	balance, err = service.Balance(testDB, "A")
+	require.NoError(t, err)
+	require.Equal(t, 800, balance)
+}
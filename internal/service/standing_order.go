@@ -0,0 +1,270 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// ErrNotActive is returned when resuming or cancelling a standing order that is finished or cancelled
+var ErrNotActive = errors.New("standing order is not active")
+
+// DefaultRetryInterval is the RetryInterval of standing orders created without one
+var DefaultRetryInterval = time.Minute
+
+// StandingOrderSpec describes a standing order to create
+type StandingOrderSpec struct {
+	From   string
+	To     string
+	Amount int
+	// Exactly one of Cron and Interval is set
+	Cron     string
+	Interval time.Duration
+	// StartAt is the earliest time of the first run, now if zero
+	StartAt time.Time
+	EndAt   *time.Time
+	MaxRuns int
+	// OnInsufficientBalance defaults to PolicySkip
+	OnInsufficientBalance models.FailurePolicy
+	MaxRetries            int
+	RetryInterval         time.Duration
+}
+
+// CreateStandingOrder persists a standing order and schedules its first run
+func CreateStandingOrder(db *gorm.DB, spec StandingOrderSpec) (*models.StandingOrder, error) {
+	if err := validate(spec.From, spec.To, spec.Amount); err != nil {
+		return nil, err
+	}
+	if (spec.Cron == "") == (spec.Interval == 0) {
+		return nil, invalidArgument("exactly one of cron and interval must be set")
+	}
+	if spec.Interval != 0 && spec.Interval < time.Second {
+		return nil, invalidArgument("interval must be at least 1 second")
+	}
+	if spec.MaxRuns < 0 || spec.MaxRetries < 0 || spec.RetryInterval < 0 {
+		return nil, invalidArgument("max runs, max retries and retry interval must not be negative")
+	}
+	switch spec.OnInsufficientBalance {
+	case "":
+		spec.OnInsufficientBalance = models.PolicySkip
+	case models.PolicySkip, models.PolicyRetry, models.PolicySuspend:
+	default:
+		return nil, invalidArgument("unknown insufficient balance policy %q", spec.OnInsufficientBalance)
+	}
+	if spec.RetryInterval == 0 {
+		spec.RetryInterval = DefaultRetryInterval
+	}
+	if spec.StartAt.IsZero() {
+		spec.StartAt = time.Now()
+	}
+
+	order := &models.StandingOrder{
+		FromAddress:           spec.From,
+		ToAddress:             spec.To,
+		Amount:                spec.Amount,
+		Cron:                  spec.Cron,
+		Interval:              spec.Interval,
+		EndAt:                 spec.EndAt,
+		MaxRuns:               spec.MaxRuns,
+		OnInsufficientBalance: spec.OnInsufficientBalance,
+		MaxRetries:            spec.MaxRetries,
+		RetryInterval:         spec.RetryInterval,
+		Status:                models.StandingOrderActive,
+	}
+
+	// Interval orders first run at the start, cron orders at the first matching time from the start
+	first := spec.StartAt
+	if spec.Cron != "" {
+		schedule, err := cron.ParseStandard(spec.Cron)
+		if err != nil {
+			return nil, invalidArgument("invalid cron expression: %v", err)
+		}
+		first = schedule.Next(spec.StartAt.Add(-time.Second))
+	}
+	if order.EndAt != nil && first.After(*order.EndAt) {
+		return nil, invalidArgument("the standing order ends before its first run")
+	}
+	order.DueAt, order.NextRunAt = first, first
+
+	if err := db.Create(order).Error; err != nil {
+		return nil, fmt.Errorf("failed to create standing order: %w", err)
+	}
+	return order, nil
+}
+
+// StandingOrder loads the standing order with the given ID
+func StandingOrder(db *gorm.DB, id uint) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	if err := db.First(&order, id).Error; err != nil {
+		return nil, fmt.Errorf("standing order not found: %w", err)
+	}
+	return &order, nil
+}
+
+// StandingOrderRuns lists the ledger entries of the standing order, newest first
+func StandingOrderRuns(db *gorm.DB, id uint, limit int) ([]models.Transfer, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	var runs []models.Transfer
+	err := db.Where("standing_order_id = ?", id).Order("id DESC").Limit(limit).Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load standing order runs: %w", err)
+	}
+	return runs, nil
+}
+
+// CancelStandingOrder stops an active or suspended standing order for good
+func CancelStandingOrder(db *gorm.DB, id uint) (*models.StandingOrder, error) {
+	return setStandingOrderStatus(db, id, models.StandingOrderCancelled,
+		models.StandingOrderActive, models.StandingOrderSuspended)
+}
+
+// ResumeStandingOrder reactivates a suspended standing order, its pending run is attempted right away
+func ResumeStandingOrder(db *gorm.DB, id uint) (*models.StandingOrder, error) {
+	return setStandingOrderStatus(db, id, models.StandingOrderActive, models.StandingOrderSuspended)
+}
+
+func setStandingOrderStatus(db *gorm.DB, id uint, status models.StandingOrderStatus, from ...models.StandingOrderStatus) (*models.StandingOrder, error) {
+	// The update waits for a scheduler running the order and then sees its new status
+	res := db.Model(&models.StandingOrder{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(map[string]any{"status": status, "retries": 0})
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to update standing order: %w", res.Error)
+	}
+
+	order, err := StandingOrder(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotActive, order.Status)
+	}
+	return order, nil
+}
+
+// RunStandingOrders runs a batch of the standing orders due at the given time and returns their number.
+// Like scheduled transfers, orders locked by another scheduler are skipped.
+func (s *Scheduler) RunStandingOrders(now time.Time) (int, error) {
+	if paused, err := s.paused(); paused || err != nil {
+		return 0, err
+	}
+
+	var due []models.StandingOrder
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", models.StandingOrderActive, now).
+			Order("next_run_at, id").
+			Limit(s.config.BatchSize).
+			Find(&due).Error
+		if err != nil {
+			return fmt.Errorf("failed to load due standing orders: %w", err)
+		}
+
+		for i := range due {
+			order := &due[i]
+			if err := runStandingOrder(tx, order, now); err != nil {
+				return err
+			}
+			if err := tx.Save(order).Error; err != nil {
+				return fmt.Errorf("failed to record standing order: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return len(due), nil
+}
+
+// runStandingOrder attempts the pending run of the order and schedules the next attempt
+func runStandingOrder(tx *gorm.DB, order *models.StandingOrder, now time.Time) error {
+	// The transfer runs in a savepoint, a failed run doesn't roll back the others
+	entry := &models.Transfer{
+		Kind:            models.KindTransfer,
+		FromAddress:     order.FromAddress,
+		ToAddress:       order.ToAddress,
+		Amount:          order.Amount,
+		StandingOrderID: &order.ID,
+	}
+	_, err := transferEntry(tx, DefaultStrategy, entry)
+	if err == nil {
+		order.LastError = ""
+		return advanceStandingOrder(order, now)
+	}
+
+	// Failed attempts are recorded in the ledger as well
+	failed := &models.Transfer{
+		Kind:            models.KindTransfer,
+		FromAddress:     order.FromAddress,
+		ToAddress:       order.ToAddress,
+		Amount:          order.Amount,
+		Status:          models.TransferFailed,
+		Reason:          err.Error(),
+		StandingOrderID: &order.ID,
+	}
+	if err := tx.Create(failed).Error; err != nil {
+		return fmt.Errorf("failed to record standing order run: %w", err)
+	}
+	if err := publishTransfer(tx, EventTransferFailed, failed); err != nil {
+		return err
+	}
+	order.LastError = err.Error()
+
+	var insufficient *InsufficientBalanceError
+	if !errors.As(err, &insufficient) {
+		// Missing or frozen wallets need someone to step in
+		order.Status = models.StandingOrderSuspended
+		return nil
+	}
+
+	switch order.OnInsufficientBalance {
+	case models.PolicyRetry:
+		if order.Retries < order.MaxRetries {
+			order.Retries++
+			order.NextRunAt = now.Add(order.RetryInterval)
+			return nil
+		}
+		return advanceStandingOrder(order, now)
+	case models.PolicySuspend:
+		order.Status = models.StandingOrderSuspended
+		return nil
+	default:
+		return advanceStandingOrder(order, now)
+	}
+}
+
+// advanceStandingOrder counts the pending run as done and schedules the next one, or finishes the order.
+// Runs missed while no scheduler was running are not caught up.
+func advanceStandingOrder(order *models.StandingOrder, now time.Time) error {
+	order.Runs++
+	order.Retries = 0
+
+	var next time.Time
+	if order.Cron != "" {
+		schedule, err := cron.ParseStandard(order.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron expression of standing order %d: %w", order.ID, err)
+		}
+		next = schedule.Next(now)
+	} else {
+		// Stay in phase with the first run
+		next = order.DueAt.Add((now.Sub(order.DueAt)/order.Interval + 1) * order.Interval)
+	}
+
+	if (order.MaxRuns > 0 && order.Runs >= order.MaxRuns) || (order.EndAt != nil && next.After(*order.EndAt)) {
+		order.Status = models.StandingOrderFinished
+		return nil
+	}
+	order.DueAt, order.NextRunAt = next, next
+	return nil
+}
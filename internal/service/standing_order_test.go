@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestStandingOrder_IntervalWithMaxRuns(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+	start := time.Now().Add(-time.Minute)
+	order, err := service.CreateStandingOrder(testDB, service.StandingOrderSpec{
+		From: "A", To: "B", Amount: 2, Interval: time.Hour, StartAt: start, MaxRuns: 2,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, start, order.NextRunAt, time.Millisecond)
+
+	scheduler := service.NewScheduler(testDB, service.SchedulerConfig{})
+	executed, err := scheduler.RunStandingOrders(start)
+	require.NoError(t, err)
+	require.Equal(t, 1, executed)
+
+	// The next run stays in phase with the first one
+	order, err = service.StandingOrder(testDB, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, order.Runs)
+	require.WithinDuration(t, start.Add(time.Hour), order.NextRunAt, time.Millisecond)
+
+	executed, err = scheduler.RunStandingOrders(start.Add(90 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, executed)
+
+	order, err = service.StandingOrder(testDB, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StandingOrderFinished, order.Status)
+
+	runs, err := service.StandingOrderRuns(testDB, order.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for _, run := range runs {
+		require.Equal(t, models.TransferCompleted, run.Status)
+	}
+
+	balance, err := service.Balance(testDB, "B")
+	require.NoError(t, err)
+	require.Equal(t, 4, balance)
+}
+
+func TestStandingOrder_InsufficientBalancePolicies(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1}).Error)
+
+	now := time.Now()
+	create := func(policy models.FailurePolicy) *models.StandingOrder {
+		order, err := service.CreateStandingOrder(testDB, service.StandingOrderSpec{
+			From: "A", To: "B", Amount: 5, Cron: "0 9 * * *", StartAt: now.Add(-48 * time.Hour),
+			OnInsufficientBalance: policy, MaxRetries: 1, RetryInterval: time.Minute,
+		})
+		require.NoError(t, err)
+		return order
+	}
+	skip, retry, suspend := create(models.PolicySkip), create(models.PolicyRetry), create(models.PolicySuspend)
+
+	scheduler := service.NewScheduler(testDB, service.SchedulerConfig{})
+	executed, err := scheduler.RunStandingOrders(now)
+	require.NoError(t, err)
+	require.Equal(t, 3, executed)
+
+	skip, err = service.StandingOrder(testDB, skip.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StandingOrderActive, skip.Status)
+	require.Equal(t, 1, skip.Runs)
+	require.True(t, skip.NextRunAt.After(now))
+	require.Contains(t, skip.LastError, "insufficient balance")
+
+	retry, err = service.StandingOrder(testDB, retry.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, retry.Retries)
+	require.Zero(t, retry.Runs)
+	require.WithinDuration(t, now.Add(time.Minute), retry.NextRunAt, time.Millisecond)
+
+	suspend, err = service.StandingOrder(testDB, suspend.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StandingOrderSuspended, suspend.Status)
+
+	// Every failed attempt is in the ledger
+	runs, err := service.StandingOrderRuns(testDB, retry.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, models.TransferFailed, runs[0].Status)
+
+	// Resumed orders attempt their pending run again
+	require.NoError(t, testDB.Model(&models.Wallet{}).Where("address = ?", "A").Update("balance", 5).Error)
+	_, err = service.ResumeStandingOrder(testDB, suspend.ID)
+	require.NoError(t, err)
+
+	executed, err = scheduler.RunStandingOrders(now)
+	require.NoError(t, err)
+	require.Equal(t, 1, executed)
+
+	suspend, err = service.StandingOrder(testDB, suspend.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StandingOrderActive, suspend.Status)
+	require.Equal(t, 1, suspend.Runs)
+
+	_, err = service.ResumeStandingOrder(testDB, suspend.ID)
+	require.ErrorIs(t, err, service.ErrNotActive)
+}
+
+func TestCreateStandingOrder_Validation(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	_, err := service.CreateStandingOrder(testDB, service.StandingOrderSpec{From: "A", To: "B", Amount: 1})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	_, err = service.CreateStandingOrder(testDB, service.StandingOrderSpec{From: "A", To: "B", Amount: 1, Cron: "every day"})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+
+	_, err = service.CreateStandingOrder(testDB, service.StandingOrderSpec{
+		From: "A", To: "B", Amount: 1, Interval: time.Hour, OnInsufficientBalance: "PANIC",
+	})
+	require.ErrorIs(t, err, service.ErrInvalidArgument)
+}
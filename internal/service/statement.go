@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// Statement covers the ledger entries of a wallet numbered for the changes feed in [From, To)
+type Statement struct {
+	Address string
+	From    time.Time
+	To      time.Time
+	// Opening is the balance before the first entry of the statement, held balance included
+	Opening int
+
+	first int64
+	last  int64
+}
+
+// StatementEntry is a debit or credit of the wallet, with the balance after it
+type StatementEntry struct {
+	ID   uint
+	Time time.Time
+	Kind models.TransferKind
+	// Counterparty is the other wallet, empty for minted and burned tokens
+	Counterparty string
+	Debit        int
+	Credit       int
+	Balance      int
+}
+
+// OpenStatement computes the opening balance of the wallet's statement from its balance checkpoints
+func OpenStatement(db *gorm.DB, address string, from time.Time, to time.Time) (*Statement, error) {
+	if !from.Before(to) {
+		return nil, invalidArgument("statement must start before it ends")
+	}
+
+	statement := &Statement{Address: address, From: from, To: to}
+	var err error
+	if statement.first, err = lastSequence(db, "sequenced_at < ?", from); err != nil {
+		return nil, err
+	}
+	if statement.last, err = lastSequence(db, "sequenced_at < ?", to); err != nil {
+		return nil, err
+	}
+
+	opening, err := BalanceAt(db, address, statement.first)
+	if err != nil {
+		return nil, err
+	}
+	statement.Opening = opening
+	return statement, nil
+}
+
+// Closing computes the balance after the last entry of the statement from the balance checkpoints,
+// for formats that need it before the entries
+func (s *Statement) Closing(db *gorm.DB) (int, error) {
+	return BalanceAt(db, s.Address, s.last)
+}
+
+// Entries calls fn for every entry of the statement, oldest first, loading them page by page.
+// It returns the closing balance.
+func (s *Statement) Entries(db *gorm.DB, fn func(StatementEntry) error) (int, error) {
+	balance, after := s.Opening, s.first
+	for {
+		var transfers []models.Transfer
+		err := db.Where("sequence > ? AND sequence <= ?", after, s.last).
+			Where("from_address = ? OR to_address = ?", s.Address, s.Address).
+			Order("sequence").
+			Limit(MaxPageSize).
+			Find(&transfers).Error
+		if err != nil {
+			return 0, fmt.Errorf("failed to load transfers: %w", err)
+		}
+
+		for _, t := range transfers {
+			entry := StatementEntry{ID: t.ID, Time: t.CreatedAt, Kind: t.Kind}
+			if t.FromAddress == s.Address {
+				entry.Counterparty, entry.Debit = t.ToAddress, t.Amount
+			} else {
+				entry.Counterparty, entry.Credit = t.FromAddress, t.Amount
+			}
+			balance += entry.Credit - entry.Debit
+			entry.Balance = balance
+
+			if err := fn(entry); err != nil {
+				return 0, err
+			}
+		}
+
+		if len(transfers) < MaxPageSize {
+			return balance, nil
+		}
+		after = *transfers[len(transfers)-1].Sequence
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"token-transfer-api/internal/models"
+)
+
+// Strategy moves the tokens between two wallets inside an already opened transaction
+type Strategy interface {
+	Name() string
+	transfer(tx *gorm.DB, from string, to string, amount int) (int, error)
+}
+
+// DefaultOptimisticRetries is the number of compare-and-swap retries used by ParseStrategy
+const DefaultOptimisticRetries = 10
+
+var (
+	// Pessimistic locks both wallets with SELECT ... FOR NO KEY UPDATE in address order
+	Pessimistic Strategy = pessimistic{}
+
+	// SingleStatement debits and credits each wallet with one conditional UPDATE statement
+	SingleStatement Strategy = singleStatement{}
+)
+
+// Optimistic reads the wallets without locking and updates them with a version compare-and-swap,
+// retrying up to maxRetries times when another transaction changed the wallets in between
+func Optimistic(maxRetries int) Strategy {
+	return optimistic{maxRetries: maxRetries}
+}
+
+// ParseStrategy returns the strategy with the given name, an empty name selects the pessimistic one
+func ParseStrategy(name string) (Strategy, error) {
+	switch name {
+	case "", "pessimistic":
+		return Pessimistic, nil
+	case "optimistic":
+		return Optimistic(DefaultOptimisticRetries), nil
+	case "single-statement":
+		return SingleStatement, nil
+	default:
+		return nil, fmt.Errorf("unknown transfer strategy %q", name)
+	}
+}
+
+type optimistic struct {
+	maxRetries int
+}
+
+func (optimistic) Name() string { return "optimistic" }
+
+func (s optimistic) transfer(tx *gorm.DB, from string, to string, amount int) (int, error) {
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		// Each attempt runs in its own savepoint so a half-applied attempt can be undone
+		if err := tx.SavePoint("optimistic_attempt").Error; err != nil {
+			return 0, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		balance, ok, err := s.attempt(tx, from, to, amount)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return balance, nil
+		}
+
+		if err := tx.RollbackTo("optimistic_attempt").Error; err != nil {
+			return 0, fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+	}
+
+	return 0, fmt.Errorf("%w %d times", ErrConflict, s.maxRetries+1)
+}
+
+// attempt performs a single read-then-compare-and-swap round, ok is false on a version conflict
+func (optimistic) attempt(tx *gorm.DB, from string, to string, amount int) (int, bool, error) {
+	var sender, receiver models.Wallet
+
+	if err := tx.First(&sender, "address = ?", from).Error; err != nil {
+		return 0, false, fmt.Errorf("sender %w: %w", ErrWalletNotFound, err)
+	}
+	if sender.Balance < amount {
+		return 0, false, &InsufficientBalanceError{Required: amount, Available: sender.Balance}
+	}
+
+	// If the receiver doesn't exist, initialize a new wallet with 0 balance
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Wallet{Address: to}).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to create receiver wallet: %w", err)
+	}
+	if err := tx.First(&receiver, "address = ?", to).Error; err != nil {
+		return 0, false, fmt.Errorf("receiver %w: %w", ErrWalletNotFound, err)
+	}
+
+	// Swap in alphabetical order of addresses, the same order the pessimistic strategy locks in
+	wallets := []struct {
+		wallet *models.Wallet
+		delta  int
+	}{{&sender, -amount}, {&receiver, amount}}
+	if to < from {
+		wallets[0], wallets[1] = wallets[1], wallets[0]
+	}
+
+	for _, w := range wallets {
+		res := tx.Model(&models.Wallet{}).
+			Where("address = ? AND version = ?", w.wallet.Address, w.wallet.Version).
+			Updates(map[string]any{
+				"balance": gorm.Expr("balance + ?", w.delta),
+				"version": gorm.Expr("version + 1"),
+			})
+		if res.Error != nil {
+			return 0, false, fmt.Errorf("failed to update wallet %s: %w", w.wallet.Address, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return 0, false, nil
+		}
+	}
+
+	return sender.Balance - amount, true, nil
+}
+
+type singleStatement struct{}
+
+func (singleStatement) Name() string { return "single-statement" }
+
+func (s singleStatement) transfer(tx *gorm.DB, from string, to string, amount int) (int, error) {
+	var balance int
+
+	// Run both statements in alphabetical order of addresses to avoid deadlocks
+	if from < to {
+		var err error
+		if balance, err = s.debit(tx, from, amount); err != nil {
+			return 0, err
+		}
+		if err := s.credit(tx, to, amount); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := s.credit(tx, to, amount); err != nil {
+			return 0, err
+		}
+		var err error
+		if balance, err = s.debit(tx, from, amount); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+// debit subtracts the amount only if the wallet holds enough tokens and returns the new balance
+func (singleStatement) debit(tx *gorm.DB, address string, amount int) (int, error) {
+	var balances []int
+	err := tx.Raw(
+		"UPDATE wallets SET balance = balance - ?, version = version + 1 WHERE address = ? AND balance >= ? RETURNING balance",
+		amount, address, amount,
+	).Scan(&balances).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to update sender: %w", err)
+	}
+	if len(balances) == 1 {
+		return balances[0], nil
+	}
+
+	// Nothing was updated, find out whether the wallet is missing or just short of tokens
+	var sender models.Wallet
+	if err := tx.First(&sender, "address = ?", address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("sender %w: %w", ErrWalletNotFound, err)
+		}
+		return 0, fmt.Errorf("failed to load sender: %w", err)
+	}
+	return 0, &InsufficientBalanceError{Required: amount, Available: sender.Balance}
+}
+
+// credit adds the amount to the wallet, creating it when it doesn't exist yet
+func (singleStatement) credit(tx *gorm.DB, address string, amount int) error {
+	err := tx.Exec(
+		"INSERT INTO wallets (address, balance, version) VALUES (?, ?, 1) "+
+			"ON CONFLICT (address) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, version = wallets.version + 1",
+		address, amount,
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to update receiver: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+var strategies = []service.Strategy{
+	service.Pessimistic,
+	service.Optimistic(service.DefaultOptimisticRetries),
+	service.SingleStatement,
+}
+
+func TestTransferWith_Success(t *testing.T) {
+	for _, strategy := range strategies {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			testDB := dbtest.Setup(t)
+
+			require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
+
+			newBalance, err := service.TransferWith(testDB, strategy, "A", "B", 4)
+			require.NoError(t, err)
+			require.Equal(t, 6, newBalance)
+
+			var walletB models.Wallet
+			require.NoError(t, testDB.First(&walletB, "address = ?", "B").Error)
+			require.Equal(t, 4, walletB.Balance)
+		})
+	}
+}
+
+func TestTransferWith_InsufficientBalance(t *testing.T) {
+	for _, strategy := range strategies {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			testDB := dbtest.Setup(t)
+
+			require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 10}).Error)
+
+			_, err := service.TransferWith(testDB, strategy, "B", "A", 20)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "insufficient balance")
+
+			// The receiver created inside the failed transaction must be rolled back
+			var count int64
+			require.NoError(t, testDB.Model(&models.Wallet{}).Where("address = ?", "A").Count(&count).Error)
+			require.Zero(t, count)
+		})
+	}
+}
+
+func TestTransferWith_WalletNotFound(t *testing.T) {
+	for _, strategy := range strategies {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			testDB := dbtest.Setup(t)
+
+			_, err := service.TransferWith(testDB, strategy, "B", "A", 10)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "sender wallet not found")
+		})
+	}
+}
+
+func TestTransferWith_ConcurrentOppositeTransfers(t *testing.T) {
+	for _, strategy := range strategies {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			testDB := dbtest.Setup(t)
+
+			require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
+			require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 1000}).Error)
+
+			type result struct {
+				// delta is what the transfer adds to A's balance
+				delta int
+				err   error
+			}
+			results := make(chan result, 100)
+
+			var wg sync.WaitGroup
+			wg.Add(100)
+			for i := 0; i < 50; i++ {
+				go func() {
+					defer wg.Done()
+					_, err := service.TransferWith(testDB, strategy, "A", "B", 3)
+					results <- result{delta: -3, err: err}
+				}()
+
+				go func() {
+					defer wg.Done()
+					_, err := service.TransferWith(testDB, strategy, "B", "A", 5)
+					results <- result{delta: 5, err: err}
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			// The optimistic strategy may run out of retries, the other ones never fail here
+			expected := 1000
+			for r := range results {
+				if r.err != nil {
+					require.Equal(t, "optimistic", strategy.Name(), "unexpected error: %v", r.err)
+					require.ErrorIs(t, r.err, service.ErrConflict)
+					continue
+				}
+				expected += r.delta
+			}
+
+			var walletA, walletB models.Wallet
+			require.NoError(t, testDB.First(&walletA, "address = ?", "A").Error)
+			require.NoError(t, testDB.First(&walletB, "address = ?", "B").Error)
+
+			require.Equal(t, expected, walletA.Balance)
+			require.Equal(t, 2000, walletA.Balance+walletB.Balance)
+		})
+	}
+}
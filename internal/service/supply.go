@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"token-transfer-api/internal/models"
+)
+
+// Mint creates new tokens in the wallet, creating the wallet if it doesn't exist yet
+func Mint(db *gorm.DB, to string, amount int) (int, error) {
+	if amount <= 0 {
+		return 0, invalidArgument("mint amount must be greater than 0")
+	}
+
+	var updatedBalance int
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := credit(tx, to, amount); err != nil {
+			return err
+		}
+
+		wallet, err := Wallet(tx, to)
+		if err != nil {
+			return err
+		}
+		updatedBalance = wallet.Balance
+
+		entry := &models.Transfer{Kind: models.KindMint, ToAddress: to, Amount: amount}
+		if err := recordCompleted(tx, entry); err != nil {
+			return err
+		}
+		return appendEvent(tx, models.EventMinted, models.EventData{To: to, Amount: amount, TransferID: &entry.ID})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedBalance, nil
+}
+
+// Burn destroys tokens held by the wallet
+func Burn(db *gorm.DB, from string, amount int) (int, error) {
+	if amount <= 0 {
+		return 0, invalidArgument("burn amount must be greater than 0")
+	}
+
+	var updatedBalance int
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if updatedBalance, err = debit(tx, from, amount); err != nil {
+			return err
+		}
+
+		entry := &models.Transfer{Kind: models.KindBurn, FromAddress: from, Amount: amount}
+		if err := recordCompleted(tx, entry); err != nil {
+			return err
+		}
+		return appendEvent(tx, models.EventBurned, models.EventData{From: from, Amount: amount, TransferID: &entry.ID})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedBalance, nil
+}
+
+// debit subtracts the amount from a single wallet, sharded or not, and returns its new balance
+func debit(tx *gorm.DB, address string, amount int) (int, error) {
+	sharded, err := shardedWallets(tx, address, "")
+	if err != nil {
+		return 0, err
+	}
+	if err := checkTransferable(tx, address, ""); err != nil {
+		return 0, err
+	}
+	if _, ok := sharded[address]; ok {
+		return debitShards(tx, address, amount)
+	}
+	return singleStatement{}.debit(tx, address, amount)
+}
+
+// credit adds the amount to a single wallet, sharded or not, creating it if it doesn't exist yet
+func credit(tx *gorm.DB, address string, amount int) error {
+	sharded, err := shardedWallets(tx, "", address)
+	if err != nil {
+		return err
+	}
+	if err := checkTransferable(tx, "", address); err != nil {
+		return err
+	}
+	if slots, ok := sharded[address]; ok {
+		return creditShards(tx, address, slots, amount)
+	}
+	return singleStatement{}.credit(tx, address, amount)
+}
+
+// recordCompleted records the entry in the ledger as completed and publishes its event
+func recordCompleted(tx *gorm.DB, transfer *models.Transfer) error {
+	pending := transfer.ID != 0
+	transfer.Status = models.TransferCompleted
+	transfer.Reason = ""
+	if err := tx.Save(transfer).Error; err != nil {
+		return fmt.Errorf("failed to record transfer: %w", err)
+	}
+	if pending {
+		// The entry was inserted by an earlier transaction, it is settled by this one
+		err := tx.Model(transfer).UpdateColumn("settled_xid", gorm.Expr(currentXID)).Error
+		if err != nil {
+			return fmt.Errorf("failed to record transfer: %w", err)
+		}
+	}
+	return publishTransfer(tx, EventTransferCompleted, transfer)
+}
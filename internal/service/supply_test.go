@@ -0,0 +1,28 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+func TestMintAndBurn(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	balance, err := service.Mint(testDB, "A", 100)
+	require.NoError(t, err)
+	require.Equal(t, 100, balance)
+
+	balance, err = service.Burn(testDB, "A", 30)
+	require.NoError(t, err)
+	require.Equal(t, 70, balance)
+
+	_, err = service.Burn(testDB, "A", 100)
+	require.ErrorContains(t, err, "insufficient balance")
+
+	var kinds []models.TransferKind
+	require.NoError(t, testDB.Model(&models.Transfer{}).Order("id").Pluck("kind", &kinds).Error)
+	require.Equal(t, []models.TransferKind{models.KindMint, models.KindBurn}, kinds)
+}
@@ -5,95 +5,233 @@ import (
 	"fmt"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"strings"
 	"token-transfer-api/internal/models"
 )
 
+// DefaultStrategy is the concurrency strategy used by Transfer
+var DefaultStrategy Strategy = Pessimistic
+
 // Transfer the tokens between wallets
 func Transfer(db *gorm.DB, from string, to string, amount int) (int, error) {
-	if amount <= 0 {
-		return 0, errors.New("transfer amount must be greater than 0")
+	return TransferWith(db, DefaultStrategy, from, to, amount)
+}
+
+// TransferRecorded transfers the tokens like Transfer and also returns the completed ledger entry,
+// including the fee charged to the sender
+func TransferRecorded(db *gorm.DB, from string, to string, amount int) (*models.Transfer, int, error) {
+	entry := &models.Transfer{Kind: models.KindTransfer, FromAddress: from, ToAddress: to, Amount: amount}
+	balance, err := transferEntry(db, DefaultStrategy, entry)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entry, balance, nil
+}
+
+// TransferWith transfers the tokens between wallets using the given concurrency strategy
+func TransferWith(db *gorm.DB, strategy Strategy, from string, to string, amount int) (int, error) {
+	return transferEntry(db, strategy, &models.Transfer{Kind: models.KindTransfer, FromAddress: from, ToAddress: to, Amount: amount})
+}
+
+// transferEntry applies the transfer in a transaction, or in a savepoint if db already is in one,
+// and records it as the given ledger entry
+func transferEntry(db *gorm.DB, strategy Strategy, transfer *models.Transfer) (int, error) {
+	if err := validate(transfer.FromAddress, transfer.ToAddress, transfer.Amount); err != nil {
+		return 0, err
 	}
 
 	var updatedBalance int
 
-	// Determine the order of addresses for locking in alphabetical order to avoid deadlocks
 	err := db.Transaction(func(tx *gorm.DB) error {
-		var firstAddr, secondAddr string
-		if from < to {
-			firstAddr, secondAddr = from, to
-		} else {
-			firstAddr, secondAddr = to, from
+		var err error
+		updatedBalance, err = execute(tx, strategy, transfer)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedBalance, nil
+}
+
+func validate(from string, to string, amount int) error {
+	if amount <= 0 {
+		return invalidArgument("transfer amount must be greater than 0")
+	}
+	if from == to {
+		return invalidArgument("sender and receiver must be different wallets")
+	}
+	return nil
+}
+
+// execute moves the tokens inside an open transaction, enforces the spending limits, charges the fee
+// and records the transfer as completed.
+// It returns the new balance of the sender.
+func execute(tx *gorm.DB, strategy Strategy, transfer *models.Transfer) (int, error) {
+	from, to, amount := transfer.FromAddress, transfer.ToAddress, transfer.Amount
+
+	// The schedule is read in the transaction, so a fee change applies to the very next transfer
+	schedule, err := FeeSchedule(tx)
+	if err != nil {
+		return 0, err
+	}
+	charged := fee(schedule, from, amount)
+
+	// Sharded hot wallets are debited and credited slot by slot, whatever the strategy
+	sharded, err := shardedWallets(tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkTransferable(tx, from, to); err != nil {
+		return 0, err
+	}
+
+	// The fee credits the treasury after both wallets were updated, so its row is locked together with
+	// theirs in address order up front, or two transfers could lock the same rows in opposite orders
+	if charged > 0 {
+		if err := lockWallets(tx, from, to, schedule.Treasury); err != nil {
+			return 0, err
 		}
+	}
 
-		var firstWallet, secondWallet models.Wallet
+	var balance int
+	if len(sharded) > 0 {
+		balance, err = transferSharded(tx, from, to, amount, sharded)
+	} else {
+		balance, err = strategy.transfer(tx, from, to, amount)
+	}
+	if err != nil {
+		return 0, err
+	}
 
-		// Lock first wallet
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&firstWallet, "address = ?", firstAddr).Error; err != nil {
-			if firstAddr == from {
-				return fmt.Errorf("sender wallet not found: %w", err)
-			} else {
-				return fmt.Errorf("receiver wallet not found: %w", err)
-			}
+	if err := checkLimits(tx, from, amount); err != nil {
+		return 0, err
+	}
+
+	if charged > 0 {
+		if balance, err = chargeFee(tx, from, schedule.Treasury, amount, charged); err != nil {
+			return 0, err
 		}
+	}
 
-		// Lock second wallet
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&secondWallet, "address = ?", secondAddr).Error; err != nil {
-			// If the receiver doesn't exist, initialize a new wallet with 0 balance
-			if errors.Is(err, gorm.ErrRecordNotFound) && secondAddr == to {
-				secondWallet = models.Wallet{
-					Address: to,
-					Balance: 0,
-				}
-				if err := tx.Create(&secondWallet).Error; err != nil {
-					if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint failed") {
-						// Someone else created it - load again
-						if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&secondWallet, "address = ?", to).Error; err != nil {
-							return fmt.Errorf("failed to re-load receiver after conflict: %w", err)
-						}
-					} else {
-						return fmt.Errorf("failed to create receiver wallet: %w", err)
-					}
-				}
-			} else {
-				if secondAddr == from {
-					return fmt.Errorf("sender wallet not found: %w", err)
-				} else {
-					return fmt.Errorf("receiver wallet not found: %w", err)
-				}
-			}
+	transfer.Fee = charged
+	if err := recordCompleted(tx, transfer); err != nil {
+		return 0, err
+	}
+
+	if charged > 0 {
+		if err := recordFee(tx, transfer, schedule.Treasury); err != nil {
+			return 0, err
 		}
+	}
+
+	err = appendEvent(tx, models.EventTransferExecuted, models.EventData{
+		From:       from,
+		To:         to,
+		Amount:     amount,
+		Kind:       transfer.Kind,
+		TransferID: &transfer.ID,
+		Fee:        charged,
+		Treasury:   schedule.Treasury,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// lockWallets locks the existing unsharded wallets among the addresses in alphabetical order.
+// The rows of sharded wallets are left alone, transfers only update their slots.
+func lockWallets(tx *gorm.DB, addresses ...string) error {
+	var wallets []models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+		Select("address").
+		Where("address IN ? AND shards = 0", addresses).
+		Order("address").
+		Find(&wallets).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock wallets: %w", err)
+	}
+	return nil
+}
+
+// pessimistic locks both wallets with SELECT ... FOR NO KEY UPDATE before updating them.
+// Unlike FOR UPDATE it doesn't conflict with the KEY SHARE lock shardedWallets takes first.
+type pessimistic struct{}
 
-		var sender, receiver *models.Wallet
-		if from == firstWallet.Address {
-			sender, receiver = &firstWallet, &secondWallet
+func (pessimistic) Name() string { return "pessimistic" }
+
+func (pessimistic) transfer(tx *gorm.DB, from string, to string, amount int) (int, error) {
+	// Determine the order of addresses for locking in alphabetical order to avoid deadlocks
+	var firstAddr, secondAddr string
+	if from < to {
+		firstAddr, secondAddr = from, to
+	} else {
+		firstAddr, secondAddr = to, from
+	}
+
+	var firstWallet, secondWallet models.Wallet
+
+	// Lock first wallet
+	if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).First(&firstWallet, "address = ?", firstAddr).Error; err != nil {
+		if firstAddr == from {
+			return 0, fmt.Errorf("sender %w: %w", ErrWalletNotFound, err)
 		} else {
-			sender, receiver = &secondWallet, &firstWallet
+			return 0, fmt.Errorf("receiver %w: %w", ErrWalletNotFound, err)
 		}
+	}
 
-		if sender.Balance < amount {
-			return fmt.Errorf("sender has insufficient balance: required %d, available %d", amount, sender.Balance)
+	// Lock second wallet
+	if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).First(&secondWallet, "address = ?", secondAddr).Error; err != nil {
+		// If the receiver doesn't exist, initialize a new wallet with 0 balance
+		if errors.Is(err, gorm.ErrRecordNotFound) && secondAddr == to {
+			secondWallet = models.Wallet{
+				Address: to,
+				Balance: 0,
+			}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&secondWallet)
+			if res.Error != nil {
+				return 0, fmt.Errorf("failed to create receiver wallet: %w", res.Error)
+			}
+			if res.RowsAffected == 0 {
+				// Someone else created it - load again
+				if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).First(&secondWallet, "address = ?", to).Error; err != nil {
+					return 0, fmt.Errorf("failed to re-load receiver after conflict: %w", err)
+				}
+			}
+		} else {
+			if secondAddr == from {
+				return 0, fmt.Errorf("sender %w: %w", ErrWalletNotFound, err)
+			} else {
+				return 0, fmt.Errorf("receiver %w: %w", ErrWalletNotFound, err)
+			}
 		}
+	}
 
-		// Perform the transfer
-		sender.Balance -= amount
-		receiver.Balance += amount
+	var sender, receiver *models.Wallet
+	if from == firstWallet.Address {
+		sender, receiver = &firstWallet, &secondWallet
+	} else {
+		sender, receiver = &secondWallet, &firstWallet
+	}
 
-		if err := tx.Save(sender).Error; err != nil {
-			return fmt.Errorf("failed to update sender: %w", err)
-		}
-		if err := tx.Save(receiver).Error; err != nil {
-			return fmt.Errorf("failed to update receiver: %w", err)
-		}
+	if sender.Balance < amount {
+		return 0, &InsufficientBalanceError{Required: amount, Available: sender.Balance}
+	}
 
-		updatedBalance = sender.Balance
+	// Perform the transfer
+	sender.Balance -= amount
+	receiver.Balance += amount
 
-		return nil
-	})
+	sender.Version++
+	receiver.Version++
 
-	if err != nil {
-		return 0, err
+	if err := tx.Save(sender).Error; err != nil {
+		return 0, fmt.Errorf("failed to update sender: %w", err)
+	}
+	if err := tx.Save(receiver).Error; err != nil {
+		return 0, fmt.Errorf("failed to update receiver: %w", err)
 	}
 
-	return updatedBalance, nil
+	return sender.Balance, nil
 }
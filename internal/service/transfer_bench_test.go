@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+// Number of receivers the hot wallet pays out to, fewer receivers means more contention
+const benchReceivers = 8
+
+// BenchmarkTransfer compares the strategies under contention on a single hot sender wallet.
+// Run with: go test -run '^$' -bench BenchmarkTransfer -cpu 1,8,32 ./internal/service
+func BenchmarkTransfer(b *testing.B) {
+	benchDB := db.Init()
+
+	for _, strategy := range strategies {
+		b.Run(strategy.Name(), func(b *testing.B) {
+			resetBenchWallets(b, benchDB)
+
+			var (
+				mu        sync.Mutex
+				latencies = make([]time.Duration, 0, b.N)
+				failures  atomic.Int64
+				next      atomic.Int64
+			)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				local := make([]time.Duration, 0, 128)
+				for pb.Next() {
+					to := fmt.Sprintf("R%d", next.Add(1)%benchReceivers)
+
+					start := time.Now()
+					if _, err := service.TransferWith(benchDB, strategy, "HOT", to, 1); err != nil {
+						failures.Add(1)
+					}
+					local = append(local, time.Since(start))
+				}
+
+				mu.Lock()
+				latencies = append(latencies, local...)
+				mu.Unlock()
+			})
+			b.StopTimer()
+
+			b.ReportMetric(float64(percentile(latencies, 0.99).Microseconds()), "p99-µs")
+			b.ReportMetric(float64(percentile(latencies, 0.50).Microseconds()), "p50-µs")
+			b.ReportMetric(float64(failures.Load()), "failures")
+		})
+	}
+}
+
+func resetBenchWallets(b *testing.B, benchDB *gorm.DB) {
+	b.Helper()
+
+	if err := benchDB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Wallet{}).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := benchDB.Create(&models.Wallet{Address: "HOT", Balance: 1 << 30}).Error; err != nil {
+		b.Fatal(err)
+	}
+}
+
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
@@ -2,29 +2,18 @@ package service_test
 
 import (
 	"github.com/stretchr/testify/require"
-	"gorm.io/gorm"
 	"sync"
 	"testing"
 	_ "time"
-	"token-transfer-api/internal/db"
+	"token-transfer-api/internal/dbtest"
 	"token-transfer-api/internal/models"
 	"token-transfer-api/internal/service"
 
 	_ "github.com/stretchr/testify/assert"
 )
 
-func setupTest(t *testing.T) *gorm.DB {
-	testDB := db.Init()
-
-	// Clear existing wallet data
-	err := testDB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Wallet{}).Error
-	require.NoError(t, err)
-
-	return testDB
-}
-
 func TestTransfer_Success(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
 
@@ -35,7 +24,7 @@ func TestTransfer_Success(t *testing.T) {
 }
 
 func TestTransfer_InsufficientBalance(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
 
@@ -46,7 +35,7 @@ func TestTransfer_InsufficientBalance(t *testing.T) {
 }
 
 func TestTransfer_WalletNotFound(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
 
@@ -57,7 +46,7 @@ func TestTransfer_WalletNotFound(t *testing.T) {
 }
 
 func TestTransfer_ConcurrentTransactionHandling(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
 
@@ -105,7 +94,7 @@ func TestTransfer_ConcurrentTransactionHandling(t *testing.T) {
 }
 
 func TestTransfer_ConcurrentReceiverCreation(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 10}).Error)
 
@@ -143,7 +132,7 @@ func TestTransfer_ConcurrentReceiverCreation(t *testing.T) {
 }
 
 func TestTransfer_ConcurrentDeadlock(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 100}).Error)
@@ -181,7 +170,7 @@ func TestTransfer_ConcurrentDeadlock(t *testing.T) {
 }
 
 func TestTransfer_Foo(t *testing.T) {
-	testDB := setupTest(t)
+	testDB := dbtest.Setup(t)
 
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 1000}).Error)
 	require.NoError(t, testDB.Create(&models.Wallet{Address: "B", Balance: 1000}).Error)
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"token-transfer-api/internal/models"
+)
+
+// ErrSubscriptionNotFound is returned for an unknown or deleted webhook subscription
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// WebhookConfig configures how webhook deliveries are sent and retried
+type WebhookConfig struct {
+	// Interval is how often the worker looks for new events and due deliveries
+	Interval time.Duration
+	// BatchSize is the maximum number of events or deliveries handled at once
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which a delivery is dead-lettered
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, it doubles with every further attempt up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout is the maximum duration of a single request
+	Timeout time.Duration
+}
+
+// DefaultWebhookConfig is used for every zero field of the WebhookConfig passed to NewWebhookWorker
+var DefaultWebhookConfig = WebhookConfig{
+	Interval:    time.Second,
+	BatchSize:   100,
+	MaxAttempts: 10,
+	MinBackoff:  10 * time.Second,
+	MaxBackoff:  time.Hour,
+	Timeout:     10 * time.Second,
+}
+
+// CreateWebhookSubscription subscribes the URL to the event types, the deliveries are signed with the secret
+func CreateWebhookSubscription(db *gorm.DB, rawURL string, eventTypes []string, secret string) (*models.WebhookSubscription, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, invalidArgument("url must be an absolute http or https URL")
+	}
+	if len(eventTypes) == 0 {
+		return nil, invalidArgument("at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if eventType != "*" && !slices.Contains(EventTypes, eventType) {
+			return nil, invalidArgument("unknown event type %q", eventType)
+		}
+	}
+	if secret == "" {
+		return nil, invalidArgument("secret is required")
+	}
+
+	subscription := &models.WebhookSubscription{URL: rawURL, EventTypes: eventTypes, Secret: secret, Active: true}
+	if err := db.Create(subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription deactivates the subscription, its deliveries are kept but no longer sent
+func DeleteWebhookSubscription(db *gorm.DB, id uint) (*models.WebhookSubscription, error) {
+	res := db.Model(&models.WebhookSubscription{}).Where("id = ? AND active", id).Update("active", false)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to delete webhook subscription: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	var subscription models.WebhookSubscription
+	if err := db.First(&subscription, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+// WebhookSubscriptions returns the active subscriptions
+func WebhookSubscriptions(db *gorm.DB) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("active").Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// WebhookDeliveries returns up to limit deliveries, newest first, optionally of a single subscription or status
+func WebhookDeliveries(db *gorm.DB, subscriptionID *uint, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, invalidArgument("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	query := db.Preload("Event").Order("id DESC").Limit(limit)
+	if subscriptionID != nil {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery sends the delivery again as soon as possible with a fresh number of attempts,
+// whether it was delivered or dead-lettered
+func ReplayWebhookDelivery(db *gorm.DB, id uint) (*models.WebhookDelivery, error) {
+	res := db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]any{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	})
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", res.Error)
+	}
+
+	var delivery models.WebhookDelivery
+	if err := db.Preload("Event").First(&delivery, id).Error; err != nil {
+		return nil, fmt.Errorf("webhook delivery not found: %w", err)
+	}
+	return &delivery, nil
+}
+
+// SignWebhook returns the X-Signature header of a delivery: the hex encoded HMAC-SHA256 of
+// the X-Webhook-Timestamp header, a dot and the body, keyed with the secret of the subscription
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBody is the JSON body of every delivery
+type webhookBody struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookWorker fans the outbox events out to the subscriptions and sends the deliveries.
+// Any number of workers may run in parallel, e.g. one per replica. Deliveries of the same
+// subscription may arrive out of order, receivers should use the event ID to deduplicate.
+type WebhookWorker struct {
+	db     *gorm.DB
+	config WebhookConfig
+	client *http.Client
+}
+
+func NewWebhookWorker(db *gorm.DB, config WebhookConfig) *WebhookWorker {
+	if config.Interval <= 0 {
+		config.Interval = DefaultWebhookConfig.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultWebhookConfig.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultWebhookConfig.MaxAttempts
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultWebhookConfig.MinBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultWebhookConfig.MaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookConfig.Timeout
+	}
+	return &WebhookWorker{db: db, config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+// Start runs the worker until the context is cancelled
+func (w *WebhookWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.drain("webhook dispatch", w.Dispatch)
+			w.drain("webhook deliveries", func(now time.Time) (int, error) {
+				return w.Deliver(ctx, now)
+			})
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// drain keeps running the batches while full batches are due
+func (w *WebhookWorker) drain(name string, run func(now time.Time) (int, error)) {
+	for {
+		handled, err := run(time.Now())
+		if err != nil {
+			log.Printf("Failed to run %s: %v", name, err)
+		}
+		if err != nil || handled < w.config.BatchSize {
+			return
+		}
+	}
+}
+
+// Dispatch creates a delivery per matching active subscription for a batch of new outbox events
+// and returns the number of events
+func (w *WebhookWorker) Dispatch(now time.Time) (int, error) {
+	var events []models.OutboxEvent
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("id").
+			Limit(w.config.BatchSize).
+			Find(&events).Error
+		if err != nil {
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		subscriptions, err := WebhookSubscriptions(tx)
+		if err != nil {
+			return err
+		}
+
+		var deliveries []models.WebhookDelivery
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+			for _, subscription := range subscriptions {
+				if slices.Contains(subscription.EventTypes, "*") || slices.Contains(subscription.EventTypes, event.Type) {
+					deliveries = append(deliveries, models.WebhookDelivery{
+						SubscriptionID: subscription.ID,
+						EventID:        event.ID,
+						Status:         models.DeliveryPending,
+						NextAttemptAt:  now,
+					})
+				}
+			}
+		}
+
+		if len(deliveries) > 0 {
+			if err := tx.Omit("Event").Create(&deliveries).Error; err != nil {
+				return fmt.Errorf("failed to create webhook deliveries: %w", err)
+			}
+		}
+		err = tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("dispatched_at", now).Error
+		if err != nil {
+			return fmt.Errorf("failed to mark outbox events as dispatched: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// Deliver sends a batch of the deliveries due at the given time in parallel and returns their number.
+// The deliveries are claimed by pushing their next attempt past the request timeout, so they are
+// picked up again if the worker dies while sending them.
+func (w *WebhookWorker) Deliver(ctx context.Context, now time.Time) (int, error) {
+	var due []models.WebhookDelivery
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at, id").
+			Limit(w.config.BatchSize).
+			Find(&due).Error
+		if err != nil {
+			return fmt.Errorf("failed to load due webhook deliveries: %w", err)
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(due))
+		for i, delivery := range due {
+			ids[i] = delivery.ID
+		}
+		err = tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(2*w.config.Timeout)).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		return tx.Preload("Event").Find(&due, ids).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	subscriptionIDs := make([]uint, len(due))
+	for i, delivery := range due {
+		subscriptionIDs[i] = delivery.SubscriptionID
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := w.db.Find(&subscriptions, subscriptionIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+	byID := make(map[uint]*models.WebhookSubscription, len(subscriptions))
+	for i := range subscriptions {
+		byID[subscriptions[i].ID] = &subscriptions[i]
+	}
+
+	var wg sync.WaitGroup
+	for i := range due {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			status, err := w.send(ctx, byID[delivery.SubscriptionID], delivery)
+			if err := w.record(delivery, status, err, time.Now()); err != nil {
+				log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+			}
+		}(&due[i])
+	}
+	wg.Wait()
+
+	return len(due), nil
+}
+
+// send posts the event to the subscription and returns the HTTP status of the response
+func (w *WebhookWorker) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	if subscription == nil || !subscription.Active {
+		return 0, ErrSubscriptionNotFound
+	}
+
+	body, err := json.Marshal(webhookBody{
+		ID:        strconv.FormatUint(uint64(delivery.EventID), 10),
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Signature", SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a bit of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of an attempt, a failed attempt is retried after the backoff or dead-lettered
+func (w *WebhookWorker) record(delivery *models.WebhookDelivery, status int, sendErr error, now time.Time) error {
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case errors.Is(sendErr, ErrSubscriptionNotFound) || delivery.Attempts >= w.config.MaxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.NextAttemptAt = now.Add(w.backoff(delivery.Attempts))
+		delivery.LastError = sendErr.Error()
+	}
+
+	return w.db.Model(delivery).
+		Select("status", "attempts", "response_status", "delivered_at", "next_attempt_at", "last_error").
+		Updates(delivery).Error
+}
+
+// backoff is the delay before the next attempt after the given number of failed attempts
+func (w *WebhookWorker) backoff(attempts int) time.Duration {
+	delay := w.config.MinBackoff
+	for i := 1; i < attempts && delay < w.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, w.config.MaxBackoff)
+}
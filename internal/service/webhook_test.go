@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"token-transfer-api/internal/dbtest"
+	"token-transfer-api/internal/models"
+	"token-transfer-api/internal/service"
+)
+
+// webhookReceiver records the verified deliveries and answers with the given status
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	received []map[string]any
+}
+
+func newWebhookReceiver(t *testing.T, secret string) (*webhookReceiver, *httptest.Server) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		signature := service.SignWebhook(secret, r.Header.Get("X-Webhook-Timestamp"), body)
+		require.Equal(t, signature, r.Header.Get("X-Signature"))
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(body, &event))
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.received = append(receiver.received, event)
+		w.WriteHeader(receiver.status)
+	}))
+	t.Cleanup(server.Close)
+	return receiver, server
+}
+
+func TestWebhook_Delivery(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	receiver, server := newWebhookReceiver(t, "secret")
+	_, err := service.CreateWebhookSubscription(testDB, server.URL, []string{service.EventTransferCompleted}, "secret")
+	require.NoError(t, err)
+
+	require.NoError(t, testDB.Create(&models.Wallet{Address: "A", Balance: 100}).Error)
+	entry, _, err := service.TransferRecorded(testDB, "A", "B", 30)
+	require.NoError(t, err)
+
+	worker := service.NewWebhookWorker(testDB, service.WebhookConfig{})
+	dispatched, err := worker.Dispatch(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, dispatched)
+	delivered, err := worker.Deliver(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered)
+
+	require.Len(t, receiver.received, 1)
+	require.Equal(t, service.EventTransferCompleted, receiver.received[0]["type"])
+	data := receiver.received[0]["data"].(map[string]any)
+	require.Equal(t, strconv.FormatUint(uint64(entry.ID), 10), data["id"])
+	require.Equal(t, "A", data["from"])
+	require.EqualValues(t, 30, data["amount"])
+
+	deliveries, err := service.WebhookDeliveries(testDB, nil, models.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, 1, deliveries[0].Attempts)
+	require.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+}
+
+func TestWebhook_RetryAndDeadLetter(t *testing.T) {
+	testDB := dbtest.Setup(t)
+
+	receiver, server := newWebhookReceiver(t, "secret")
+	receiver.status = http.StatusInternalServerError
+	_, err := service.CreateWebhookSubscription(testDB, server.URL, []string{"*"}, "secret")
+	require.NoError(t, err)
+
+	_, err = service.Mint(testDB, "A", 10)
+	require.NoError(t, err)
+
+	worker := service.NewWebhookWorker(testDB, service.WebhookConfig{MaxAttempts: 2, MinBackoff: time.Minute})
+	now := time.Now()
+	_, err = worker.Dispatch(now)
+	require.NoError(t, err)
+
+	delivered, err := worker.Deliver(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered)
+
+	// The retry waits for the backoff
+	delivered, err = worker.Deliver(context.Background(), now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Zero(t, delivered)
+
+	delivered, err = worker.Deliver(context.Background(), now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered)
+
+	dead, err := service.WebhookDeliveries(testDB, nil, models.DeliveryDead, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, 2, dead[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, dead[0].ResponseStatus)
+
+	// An admin replays the dead-lettered delivery once the receiver is fixed
+	receiver.mu.Lock()
+	receiver.status = http.StatusNoContent
+	receiver.mu.Unlock()
+
+	replayed, err := service.ReplayWebhookDelivery(testDB, dead[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, models.DeliveryPending, replayed.Status)
+
+	delivered, err = worker.Deliver(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered)
+	require.Len(t, receiver.received, 3)
+
+	deliveries, err := service.WebhookDeliveries(testDB, &replayed.SubscriptionID, models.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+}
@@ -0,0 +1,182 @@
+// Package statement writes account statements of a wallet in formats finance tools import directly
+package statement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"token-transfer-api/internal/iso20022"
+	"token-transfer-api/internal/service"
+)
+
+const (
+	FormatCSV       = "csv"
+	FormatNDJSON    = "ndjson"
+	FormatBeancount = "beancount"
+	// FormatCamt053 is the ISO 20022 bank-to-customer statement
+	FormatCamt053 = "camt053"
+)
+
+// Commodity is the name of the token in beancount statements
+var Commodity = "TOKEN"
+
+// ContentType returns the media type of the format, empty for unknown formats
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatBeancount:
+		return "text/plain; charset=utf-8"
+	case FormatCamt053:
+		return "application/xml"
+	}
+	return ""
+}
+
+// Write streams the statement to out in the given format, from the opening to the closing balance
+func Write(db *gorm.DB, out io.Writer, format string, statement *service.Statement) error {
+	var w writer
+	switch format {
+	case FormatCSV:
+		w = &csvWriter{csv: csv.NewWriter(out)}
+	case FormatNDJSON:
+		w = &ndjsonWriter{encoder: json.NewEncoder(out)}
+	case FormatBeancount:
+		w = &beancountWriter{out: out, account: "Assets:Wallets:" + accountName(statement.Address)}
+	case FormatCamt053:
+		return iso20022.WriteCamt053(db, out, statement)
+	default:
+		return fmt.Errorf("%w: unknown statement format %q", service.ErrInvalidArgument, format)
+	}
+
+	if err := w.opening(statement); err != nil {
+		return err
+	}
+	closing, err := statement.Entries(db, w.entry)
+	if err != nil {
+		return err
+	}
+	return w.closing(statement, closing)
+}
+
+type writer interface {
+	opening(statement *service.Statement) error
+	entry(entry service.StatementEntry) error
+	closing(statement *service.Statement, balance int) error
+}
+
+// csvWriter writes a row per entry between an opening and a closing balance row
+type csvWriter struct {
+	csv *csv.Writer
+}
+
+func (w *csvWriter) opening(statement *service.Statement) error {
+	if err := w.csv.Write([]string{"date", "id", "kind", "counterparty", "debit", "credit", "balance"}); err != nil {
+		return err
+	}
+	return w.csv.Write([]string{statement.From.Format(time.RFC3339), "", "OPENING", "", "", "", strconv.Itoa(statement.Opening)})
+}
+
+func (w *csvWriter) entry(entry service.StatementEntry) error {
+	return w.csv.Write([]string{
+		entry.Time.Format(time.RFC3339Nano), strconv.FormatUint(uint64(entry.ID), 10), string(entry.Kind), entry.Counterparty,
+		strconv.Itoa(entry.Debit), strconv.Itoa(entry.Credit), strconv.Itoa(entry.Balance),
+	})
+}
+
+func (w *csvWriter) closing(statement *service.Statement, balance int) error {
+	if err := w.csv.Write([]string{statement.To.Format(time.RFC3339), "", "CLOSING", "", "", "", strconv.Itoa(balance)}); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// ndjsonWriter writes a JSON object per line, typed opening, entry or closing
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+type ndjsonLine struct {
+	Type         string    `json:"type"`
+	Date         time.Time `json:"date"`
+	ID           string    `json:"id,omitempty"`
+	Kind         string    `json:"kind,omitempty"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Debit        int       `json:"debit,omitempty"`
+	Credit       int       `json:"credit,omitempty"`
+	Balance      int       `json:"balance"`
+}
+
+func (w *ndjsonWriter) opening(statement *service.Statement) error {
+	return w.encoder.Encode(ndjsonLine{Type: "opening", Date: statement.From, Balance: statement.Opening})
+}
+
+func (w *ndjsonWriter) entry(entry service.StatementEntry) error {
+	return w.encoder.Encode(ndjsonLine{
+		Type: "entry", Date: entry.Time, ID: strconv.FormatUint(uint64(entry.ID), 10), Kind: string(entry.Kind),
+		Counterparty: entry.Counterparty, Debit: entry.Debit, Credit: entry.Credit, Balance: entry.Balance,
+	})
+}
+
+func (w *ndjsonWriter) closing(statement *service.Statement, balance int) error {
+	return w.encoder.Encode(ndjsonLine{Type: "closing", Date: statement.To, Balance: balance})
+}
+
+// beancountWriter writes a transaction per entry against the wallet's asset account, with the opening
+// balance booked from equity and the closing balance asserted. Counterparty accounts are opened by the
+// auto_accounts plugin.
+type beancountWriter struct {
+	out     io.Writer
+	account string
+}
+
+func (w *beancountWriter) opening(statement *service.Statement) error {
+	date := statement.From.UTC().Format(time.DateOnly)
+	_, err := fmt.Fprintf(w.out, "; Statement of %s from %s to %s\noption \"operating_currency\" \"%s\"\nplugin \"beancount.plugins.auto_accounts\"\n\n"+
+		"%s open %s %s\n\n%s * \"Opening balance\"\n  %s %d %s\n  Equity:Opening-Balances\n",
+		statement.Address, statement.From.Format(time.RFC3339), statement.To.Format(time.RFC3339), Commodity,
+		date, w.account, Commodity, date, w.account, statement.Opening, Commodity)
+	return err
+}
+
+func (w *beancountWriter) entry(entry service.StatementEntry) error {
+	counterparty := "Equity:Supply"
+	if entry.Counterparty != "" {
+		counterparty = "Equity:Counterparties:" + accountName(entry.Counterparty)
+	}
+	_, err := fmt.Fprintf(w.out, "\n%s * %s \"%s %d\"\n  id: \"%d\"\n  %s %d %s\n  %s\n",
+		entry.Time.UTC().Format(time.DateOnly), strconv.Quote(entry.Counterparty), entry.Kind, entry.ID, entry.ID,
+		w.account, entry.Credit-entry.Debit, Commodity, counterparty)
+	return err
+}
+
+func (w *beancountWriter) closing(statement *service.Statement, balance int) error {
+	// Balance assertions apply at the start of the day, the day after the last entry at the latest
+	date := statement.To.UTC()
+	if date.Truncate(24*time.Hour) != date {
+		date = date.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	_, err := fmt.Fprintf(w.out, "\n%s balance %s %d %s\n", date.Format(time.DateOnly), w.account, balance, Commodity)
+	return err
+}
+
+var invalidAccountChars = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+
+// accountName turns the address into an account name component, which must start with a capital or a digit
+func accountName(address string) string {
+	name := invalidAccountChars.ReplaceAllString(address, "-")
+	name = strings.ToUpper(name[:min(len(name), 1)]) + name[min(len(name), 1):]
+	if name == "" || name[0] == '-' {
+		name = "X" + name
+	}
+	return name
+}
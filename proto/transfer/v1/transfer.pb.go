@@ -0,0 +1,964 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: transfer/v1/transfer.proto
+
+package transferv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TransferKind int32
+
+const (
+	TransferKind_TRANSFER_KIND_UNSPECIFIED TransferKind = 0
+	TransferKind_TRANSFER_KIND_TRANSFER    TransferKind = 1
+	TransferKind_TRANSFER_KIND_MINT        TransferKind = 2
+	TransferKind_TRANSFER_KIND_BURN        TransferKind = 3
+	// Fee paid by the sender of another transfer to the treasury
+	TransferKind_TRANSFER_KIND_FEE TransferKind = 4
+	// Whole remaining amount of another transfer sent back by an admin
+	TransferKind_TRANSFER_KIND_REVERSAL TransferKind = 5
+	// Amount of another transfer sent back by its receiver
+	TransferKind_TRANSFER_KIND_REFUND TransferKind = 6
+)
+
+// Enum value maps for TransferKind.
+var (
+	TransferKind_name = map[int32]string{
+		0: "TRANSFER_KIND_UNSPECIFIED",
+		1: "TRANSFER_KIND_TRANSFER",
+		2: "TRANSFER_KIND_MINT",
+		3: "TRANSFER_KIND_BURN",
+		4: "TRANSFER_KIND_FEE",
+		5: "TRANSFER_KIND_REVERSAL",
+		6: "TRANSFER_KIND_REFUND",
+	}
+	TransferKind_value = map[string]int32{
+		"TRANSFER_KIND_UNSPECIFIED": 0,
+		"TRANSFER_KIND_TRANSFER":    1,
+		"TRANSFER_KIND_MINT":        2,
+		"TRANSFER_KIND_BURN":        3,
+		"TRANSFER_KIND_FEE":         4,
+		"TRANSFER_KIND_REVERSAL":    5,
+		"TRANSFER_KIND_REFUND":      6,
+	}
+)
+
+func (x TransferKind) Enum() *TransferKind {
+	p := new(TransferKind)
+	*p = x
+	return p
+}
+
+func (x TransferKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransferKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_transfer_v1_transfer_proto_enumTypes[0].Descriptor()
+}
+
+func (TransferKind) Type() protoreflect.EnumType {
+	return &file_transfer_v1_transfer_proto_enumTypes[0]
+}
+
+func (x TransferKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransferKind.Descriptor instead.
+func (TransferKind) EnumDescriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{0}
+}
+
+type TransferStatus int32
+
+const (
+	TransferStatus_TRANSFER_STATUS_UNSPECIFIED TransferStatus = 0
+	TransferStatus_TRANSFER_STATUS_PENDING     TransferStatus = 1
+	TransferStatus_TRANSFER_STATUS_COMPLETED   TransferStatus = 2
+	TransferStatus_TRANSFER_STATUS_FAILED      TransferStatus = 3
+	// The tokens of a completed transfer were sent back
+	TransferStatus_TRANSFER_STATUS_REVERSED           TransferStatus = 4
+	TransferStatus_TRANSFER_STATUS_PARTIALLY_REFUNDED TransferStatus = 5
+	TransferStatus_TRANSFER_STATUS_REFUNDED           TransferStatus = 6
+)
+
+// Enum value maps for TransferStatus.
+var (
+	TransferStatus_name = map[int32]string{
+		0: "TRANSFER_STATUS_UNSPECIFIED",
+		1: "TRANSFER_STATUS_PENDING",
+		2: "TRANSFER_STATUS_COMPLETED",
+		3: "TRANSFER_STATUS_FAILED",
+		4: "TRANSFER_STATUS_REVERSED",
+		5: "TRANSFER_STATUS_PARTIALLY_REFUNDED",
+		6: "TRANSFER_STATUS_REFUNDED",
+	}
+	TransferStatus_value = map[string]int32{
+		"TRANSFER_STATUS_UNSPECIFIED":        0,
+		"TRANSFER_STATUS_PENDING":            1,
+		"TRANSFER_STATUS_COMPLETED":          2,
+		"TRANSFER_STATUS_FAILED":             3,
+		"TRANSFER_STATUS_REVERSED":           4,
+		"TRANSFER_STATUS_PARTIALLY_REFUNDED": 5,
+		"TRANSFER_STATUS_REFUNDED":           6,
+	}
+)
+
+func (x TransferStatus) Enum() *TransferStatus {
+	p := new(TransferStatus)
+	*p = x
+	return p
+}
+
+func (x TransferStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransferStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_transfer_v1_transfer_proto_enumTypes[1].Descriptor()
+}
+
+func (TransferStatus) Type() protoreflect.EnumType {
+	return &file_transfer_v1_transfer_proto_enumTypes[1]
+}
+
+func (x TransferStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransferStatus.Descriptor instead.
+func (TransferStatus) EnumDescriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{1}
+}
+
+type TransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Amount        int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRequest) Reset() {
+	*x = TransferRequest{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRequest) ProtoMessage() {}
+
+func (x *TransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRequest.ProtoReflect.Descriptor instead.
+func (*TransferRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TransferRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *TransferRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type TransferResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Balance of the sender after the transfer
+	Balance int64 `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	// Fee charged to the sender on top of the amount
+	Fee           int64 `protobuf:"varint,2,opt,name=fee,proto3" json:"fee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TransferResponse) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *TransferResponse) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+type GetWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletRequest) Reset() {
+	*x = GetWalletRequest{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletRequest) ProtoMessage() {}
+
+func (x *GetWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetWalletRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type GetWalletResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletResponse) Reset() {
+	*x = GetWalletResponse{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletResponse) ProtoMessage() {}
+
+func (x *GetWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletResponse.ProtoReflect.Descriptor instead.
+func (*GetWalletResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetWalletResponse) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+type Wallet struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Subject allowed to move tokens out of the wallet, empty if the wallet has no owner
+	Owner string `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Available balance, including all slots of a sharded wallet
+	Balance int64 `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	Shards  int32 `protobuf:"varint,4,opt,name=shards,proto3" json:"shards,omitempty"`
+	// Balance reserved by authorized holds
+	Held int64 `protobuf:"varint,6,opt,name=held,proto3" json:"held,omitempty"`
+	// The wallet can't send tokens
+	SendFrozen bool `protobuf:"varint,7,opt,name=send_frozen,json=sendFrozen,proto3" json:"send_frozen,omitempty"`
+	// The wallet can't receive tokens
+	ReceiveFrozen bool `protobuf:"varint,8,opt,name=receive_frozen,json=receiveFrozen,proto3" json:"receive_frozen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Wallet) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Wallet) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Wallet) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Wallet) GetShards() int32 {
+	if x != nil {
+		return x.Shards
+	}
+	return 0
+}
+
+func (x *Wallet) GetHeld() int64 {
+	if x != nil {
+		return x.Held
+	}
+	return 0
+}
+
+func (x *Wallet) GetSendFrozen() bool {
+	if x != nil {
+		return x.SendFrozen
+	}
+	return false
+}
+
+func (x *Wallet) GetReceiveFrozen() bool {
+	if x != nil {
+		return x.ReceiveFrozen
+	}
+	return false
+}
+
+type Transfer struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind  TransferKind           `protobuf:"varint,2,opt,name=kind,proto3,enum=transfer.v1.TransferKind" json:"kind,omitempty"`
+	// Empty for minted tokens
+	From string `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// Empty for burned tokens
+	To     string         `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Amount int64          `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Status TransferStatus `protobuf:"varint,6,opt,name=status,proto3,enum=transfer.v1.TransferStatus" json:"status,omitempty"`
+	// Why the transfer failed
+	Reason    string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Fee charged to the sender on top of the amount
+	Fee int64 `protobuf:"varint,10,opt,name=fee,proto3" json:"fee,omitempty"`
+	// Part of the amount sent back by reversals and refunds
+	Refunded int64 `protobuf:"varint,11,opt,name=refunded,proto3" json:"refunded,omitempty"`
+	// The transfer a fee was charged for, or a reversal or refund sent back, 0 if none
+	ParentId uint64 `protobuf:"varint,12,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	// Position of the entry in the changes feed, 0 until it is numbered
+	Sequence      int64 `protobuf:"varint,13,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transfer) Reset() {
+	*x = Transfer{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transfer) ProtoMessage() {}
+
+func (x *Transfer) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transfer.ProtoReflect.Descriptor instead.
+func (*Transfer) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Transfer) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transfer) GetKind() TransferKind {
+	if x != nil {
+		return x.Kind
+	}
+	return TransferKind_TRANSFER_KIND_UNSPECIFIED
+}
+
+func (x *Transfer) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Transfer) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Transfer) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transfer) GetStatus() TransferStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TransferStatus_TRANSFER_STATUS_UNSPECIFIED
+}
+
+func (x *Transfer) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Transfer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Transfer) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Transfer) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Transfer) GetRefunded() int64 {
+	if x != nil {
+		return x.Refunded
+	}
+	return 0
+}
+
+func (x *Transfer) GetParentId() uint64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *Transfer) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type ListTransfersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list entries sent or received by the wallet, all entries if empty
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Maximum number of entries, 50 if not set
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The next_cursor of the previous page, empty for the first page
+	Cursor        string `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransfersRequest) Reset() {
+	*x = ListTransfersRequest{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransfersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransfersRequest) ProtoMessage() {}
+
+func (x *ListTransfersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransfersRequest.ProtoReflect.Descriptor instead.
+func (*ListTransfersRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTransfersRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ListTransfersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTransfersRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListTransfersResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Transfers []*Transfer            `protobuf:"bytes,1,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	// Empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransfersResponse) Reset() {
+	*x = ListTransfersResponse{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransfersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransfersResponse) ProtoMessage() {}
+
+func (x *ListTransfersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransfersResponse.ProtoReflect.Descriptor instead.
+func (*ListTransfersResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTransfersResponse) GetTransfers() []*Transfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+func (x *ListTransfersResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type WatchTransfersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only watch entries sent or received by the wallet, all entries if empty
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Start after the entry with this sequence number, 0 streams the whole ledger. Clients resume
+	// after the sequence of the last received entry.
+	Since         int64 `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTransfersRequest) Reset() {
+	*x = WatchTransfersRequest{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTransfersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTransfersRequest) ProtoMessage() {}
+
+func (x *WatchTransfersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTransfersRequest.ProtoReflect.Descriptor instead.
+func (*WatchTransfersRequest) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchTransfersRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WatchTransfersRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+type WatchTransfersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transfer      *Transfer              `protobuf:"bytes,1,opt,name=transfer,proto3" json:"transfer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTransfersResponse) Reset() {
+	*x = WatchTransfersResponse{}
+	mi := &file_transfer_v1_transfer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTransfersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTransfersResponse) ProtoMessage() {}
+
+func (x *WatchTransfersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_v1_transfer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTransfersResponse.ProtoReflect.Descriptor instead.
+func (*WatchTransfersResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_v1_transfer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchTransfersResponse) GetTransfer() *Transfer {
+	if x != nil {
+		return x.Transfer
+	}
+	return nil
+}
+
+var File_transfer_v1_transfer_proto protoreflect.FileDescriptor
+
+const file_transfer_v1_transfer_proto_rawDesc = "" +
+	"\n" +
+	"\x1atransfer/v1/transfer.proto\x12\vtransfer.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"M\n" +
+	"\x0fTransferRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\">\n" +
+	"\x10TransferResponse\x12\x18\n" +
+	"\abalance\x18\x01 \x01(\x03R\abalance\x12\x10\n" +
+	"\x03fee\x18\x02 \x01(\x03R\x03fee\",\n" +
+	"\x10GetWalletRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"@\n" +
+	"\x11GetWalletResponse\x12+\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x13.transfer.v1.WalletR\x06wallet\"\xd4\x01\n" +
+	"\x06Wallet\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x03R\abalance\x12\x16\n" +
+	"\x06shards\x18\x04 \x01(\x05R\x06shards\x12\x12\n" +
+	"\x04held\x18\x06 \x01(\x03R\x04held\x12\x1f\n" +
+	"\vsend_frozen\x18\a \x01(\bR\n" +
+	"sendFrozen\x12%\n" +
+	"\x0ereceive_frozen\x18\b \x01(\bR\rreceiveFrozenJ\x04\b\x05\x10\x06R\x06frozen\"\xaf\x03\n" +
+	"\bTransfer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12-\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x19.transfer.v1.TransferKindR\x04kind\x12\x12\n" +
+	"\x04from\x18\x03 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x04 \x01(\tR\x02to\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\x03R\x06amount\x123\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x1b.transfer.v1.TransferStatusR\x06status\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x10\n" +
+	"\x03fee\x18\n" +
+	" \x01(\x03R\x03fee\x12\x1a\n" +
+	"\brefunded\x18\v \x01(\x03R\brefunded\x12\x1b\n" +
+	"\tparent_id\x18\f \x01(\x04R\bparentId\x12\x1a\n" +
+	"\bsequence\x18\r \x01(\x03R\bsequence\"^\n" +
+	"\x14ListTransfersRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\"m\n" +
+	"\x15ListTransfersResponse\x123\n" +
+	"\ttransfers\x18\x01 \x03(\v2\x15.transfer.v1.TransferR\ttransfers\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"W\n" +
+	"\x15WatchTransfersRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05since\x18\x03 \x01(\x03R\x05sinceJ\x04\b\x02\x10\x03R\bafter_id\"K\n" +
+	"\x16WatchTransfersResponse\x121\n" +
+	"\btransfer\x18\x01 \x01(\v2\x15.transfer.v1.TransferR\btransfer*\xc6\x01\n" +
+	"\fTransferKind\x12\x1d\n" +
+	"\x19TRANSFER_KIND_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16TRANSFER_KIND_TRANSFER\x10\x01\x12\x16\n" +
+	"\x12TRANSFER_KIND_MINT\x10\x02\x12\x16\n" +
+	"\x12TRANSFER_KIND_BURN\x10\x03\x12\x15\n" +
+	"\x11TRANSFER_KIND_FEE\x10\x04\x12\x1a\n" +
+	"\x16TRANSFER_KIND_REVERSAL\x10\x05\x12\x18\n" +
+	"\x14TRANSFER_KIND_REFUND\x10\x06*\xed\x01\n" +
+	"\x0eTransferStatus\x12\x1f\n" +
+	"\x1bTRANSFER_STATUS_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17TRANSFER_STATUS_PENDING\x10\x01\x12\x1d\n" +
+	"\x19TRANSFER_STATUS_COMPLETED\x10\x02\x12\x1a\n" +
+	"\x16TRANSFER_STATUS_FAILED\x10\x03\x12\x1c\n" +
+	"\x18TRANSFER_STATUS_REVERSED\x10\x04\x12&\n" +
+	"\"TRANSFER_STATUS_PARTIALLY_REFUNDED\x10\x05\x12\x1c\n" +
+	"\x18TRANSFER_STATUS_REFUNDED\x10\x062\xdb\x02\n" +
+	"\x0fTransferService\x12G\n" +
+	"\bTransfer\x12\x1c.transfer.v1.TransferRequest\x1a\x1d.transfer.v1.TransferResponse\x12J\n" +
+	"\tGetWallet\x12\x1d.transfer.v1.GetWalletRequest\x1a\x1e.transfer.v1.GetWalletResponse\x12V\n" +
+	"\rListTransfers\x12!.transfer.v1.ListTransfersRequest\x1a\".transfer.v1.ListTransfersResponse\x12[\n" +
+	"\x0eWatchTransfers\x12\".transfer.v1.WatchTransfersRequest\x1a#.transfer.v1.WatchTransfersResponse0\x01B1Z/token-transfer-api/proto/transfer/v1;transferv1b\x06proto3"
+
+var (
+	file_transfer_v1_transfer_proto_rawDescOnce sync.Once
+	file_transfer_v1_transfer_proto_rawDescData []byte
+)
+
+func file_transfer_v1_transfer_proto_rawDescGZIP() []byte {
+	file_transfer_v1_transfer_proto_rawDescOnce.Do(func() {
+		file_transfer_v1_transfer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_transfer_v1_transfer_proto_rawDesc), len(file_transfer_v1_transfer_proto_rawDesc)))
+	})
+	return file_transfer_v1_transfer_proto_rawDescData
+}
+
+var file_transfer_v1_transfer_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_transfer_v1_transfer_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_transfer_v1_transfer_proto_goTypes = []any{
+	(TransferKind)(0),              // 0: transfer.v1.TransferKind
+	(TransferStatus)(0),            // 1: transfer.v1.TransferStatus
+	(*TransferRequest)(nil),        // 2: transfer.v1.TransferRequest
+	(*TransferResponse)(nil),       // 3: transfer.v1.TransferResponse
+	(*GetWalletRequest)(nil),       // 4: transfer.v1.GetWalletRequest
+	(*GetWalletResponse)(nil),      // 5: transfer.v1.GetWalletResponse
+	(*Wallet)(nil),                 // 6: transfer.v1.Wallet
+	(*Transfer)(nil),               // 7: transfer.v1.Transfer
+	(*ListTransfersRequest)(nil),   // 8: transfer.v1.ListTransfersRequest
+	(*ListTransfersResponse)(nil),  // 9: transfer.v1.ListTransfersResponse
+	(*WatchTransfersRequest)(nil),  // 10: transfer.v1.WatchTransfersRequest
+	(*WatchTransfersResponse)(nil), // 11: transfer.v1.WatchTransfersResponse
+	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+}
+var file_transfer_v1_transfer_proto_depIdxs = []int32{
+	6,  // 0: transfer.v1.GetWalletResponse.wallet:type_name -> transfer.v1.Wallet
+	0,  // 1: transfer.v1.Transfer.kind:type_name -> transfer.v1.TransferKind
+	1,  // 2: transfer.v1.Transfer.status:type_name -> transfer.v1.TransferStatus
+	12, // 3: transfer.v1.Transfer.created_at:type_name -> google.protobuf.Timestamp
+	12, // 4: transfer.v1.Transfer.updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 5: transfer.v1.ListTransfersResponse.transfers:type_name -> transfer.v1.Transfer
+	7,  // 6: transfer.v1.WatchTransfersResponse.transfer:type_name -> transfer.v1.Transfer
+	2,  // 7: transfer.v1.TransferService.Transfer:input_type -> transfer.v1.TransferRequest
+	4,  // 8: transfer.v1.TransferService.GetWallet:input_type -> transfer.v1.GetWalletRequest
+	8,  // 9: transfer.v1.TransferService.ListTransfers:input_type -> transfer.v1.ListTransfersRequest
+	10, // 10: transfer.v1.TransferService.WatchTransfers:input_type -> transfer.v1.WatchTransfersRequest
+	3,  // 11: transfer.v1.TransferService.Transfer:output_type -> transfer.v1.TransferResponse
+	5,  // 12: transfer.v1.TransferService.GetWallet:output_type -> transfer.v1.GetWalletResponse
+	9,  // 13: transfer.v1.TransferService.ListTransfers:output_type -> transfer.v1.ListTransfersResponse
+	11, // 14: transfer.v1.TransferService.WatchTransfers:output_type -> transfer.v1.WatchTransfersResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_transfer_v1_transfer_proto_init() }
+func file_transfer_v1_transfer_proto_init() {
+	if File_transfer_v1_transfer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transfer_v1_transfer_proto_rawDesc), len(file_transfer_v1_transfer_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_transfer_v1_transfer_proto_goTypes,
+		DependencyIndexes: file_transfer_v1_transfer_proto_depIdxs,
+		EnumInfos:         file_transfer_v1_transfer_proto_enumTypes,
+		MessageInfos:      file_transfer_v1_transfer_proto_msgTypes,
+	}.Build()
+	File_transfer_v1_transfer_proto = out.File
+	file_transfer_v1_transfer_proto_goTypes = nil
+	file_transfer_v1_transfer_proto_depIdxs = nil
+}